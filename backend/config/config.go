@@ -5,14 +5,21 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Providers   ProvidersConfig   `yaml:"providers"`
+	Pricing     PricingConfig     `yaml:"pricing"`
+	Runs        RunsConfig        `yaml:"runs"`
+	Evaluations EvaluationsConfig `yaml:"evaluations"`
+	Trash       TrashConfig       `yaml:"trash"`
 }
 
 type ServerConfig struct {
@@ -23,17 +30,107 @@ type ServerConfig struct {
 type DatabaseConfig struct {
 	Type     string `yaml:"type"`
 	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
+	Port     int    `yaml:"port"` // 为 0 时使用数据库的默认端口
 	Name     string `yaml:"name"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
+	// DSN 完整的连接串，设置后忽略上面的连接字段（仅 mysql、postgres）
+	DSN string `yaml:"dsn"`
+	// SSLMode PostgreSQL 的 sslmode：disable、allow、prefer、require、verify-ca、verify-full，为空时使用驱动默认值 prefer
+	SSLMode string `yaml:"ssl_mode"`
+	// SSLRootCert PostgreSQL 校验服务端证书使用的 CA 证书路径
+	SSLRootCert string `yaml:"ssl_root_cert"`
 }
 
 type LoggingConfig struct {
-	Level  string `yaml:"level"`
-	Output string `yaml:"output"`
+	Level  string `yaml:"level"`  // debug、info、warn、error
+	Output string `yaml:"output"` // stdout、stderr 或日志文件路径
+	Format string `yaml:"format"` // text 或 json
+	// SlowQueryThreshold 执行时间超过该值的 SQL 以 warn 级别记录
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// 输出到文件时的滚动策略：单个文件的最大大小（MB）、保留的旧文件数量和天数（0 表示不限），以及是否压缩旧文件
+	MaxSize    int  `yaml:"max_size"`
+	MaxBackups int  `yaml:"max_backups"`
+	MaxAge     int  `yaml:"max_age"`
+	Compress   bool `yaml:"compress"`
 }
 
+type AuthConfig struct {
+	// Enabled 开启后所有 /api 请求都必须携带有效凭证；关闭时只允许匿名读取，写操作和管理员接口仍需凭证
+	Enabled bool `yaml:"enabled"`
+	// SessionTTL 登录会话有效期，如 "72h"
+	SessionTTL     time.Duration        `yaml:"session_ttl"`
+	BootstrapAdmin BootstrapAdminConfig `yaml:"bootstrap_admin"`
+}
+
+// BootstrapAdminConfig 首次启动且没有任何用户时创建的管理员账号
+type BootstrapAdminConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// ProvidersConfig 模型服务商的调用超时与重试配置
+type ProvidersConfig struct {
+	// Timeout 非流式调用的总超时，流式调用只限制等待响应头的时间
+	Timeout time.Duration `yaml:"timeout"`
+	// Timeouts 按服务商名称覆盖 Timeout，如本地部署的模型可设置更长的超时
+	Timeouts map[string]time.Duration `yaml:"timeouts"`
+	// MaxRetries 遇到 429、5xx 或网络错误时的最大重试次数，0 表示不重试
+	MaxRetries int `yaml:"max_retries"`
+	// RetryBaseDelay 指数退避的初始等待时间
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	// RetryMaxDelay 单次等待的上限，同样限制 Retry-After
+	RetryMaxDelay time.Duration `yaml:"retry_max_delay"`
+}
+
+// PricingConfig 模型价格表，用于计算每次调用的费用
+type PricingConfig struct {
+	// Currency 未单独指定币种的价格使用的币种
+	Currency string `yaml:"currency"`
+	// Models 按模型名称或 "服务商/模型名称" 配置价格，后者优先
+	Models map[string]ModelPrice `yaml:"models"`
+}
+
+// ModelPrice 每百万 tokens 的输入和输出价格
+type ModelPrice struct {
+	Input    float64 `yaml:"input"`
+	Output   float64 `yaml:"output"`
+	Currency string  `yaml:"currency"`
+}
+
+// RunsConfig 测试运行记录的保留策略
+type RunsConfig struct {
+	// Retention 运行记录的保留时长，如 "720h"，0 表示永久保留
+	Retention time.Duration `yaml:"retention"`
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	// Retention 回收站中的项目、提示词、标签和分类保留多久后彻底删除，如 "720h"，0 表示永久保留
+	Retention time.Duration `yaml:"retention"`
+}
+
+// EvaluationsConfig 批量评测配置
+type EvaluationsConfig struct {
+	// MaxConcurrency 单个评测任务同时调用模型的最大请求数，请求中的 concurrency 不能超过该值
+	MaxConcurrency int `yaml:"max_concurrency"`
+}
+
+// defaultRunRetention 默认保留 30 天的运行记录
+const defaultRunRetention = 30 * 24 * time.Hour
+
+// defaultTrashRetention 默认在回收站中保留 30 天
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// defaultSlowQueryThreshold 未配置时慢查询的判定阈值
+const defaultSlowQueryThreshold = 200 * time.Millisecond
+
+// defaultLogMaxSize 未配置时单个日志文件的最大大小（MB）
+const defaultLogMaxSize = 100
+
+// defaultEvaluationConcurrency 未配置时批量评测的最大并发数
+const defaultEvaluationConcurrency = 8
+
 // LoadConfig 从配置文件加载配置
 func LoadConfig() *Config {
 	cfg := &Config{
@@ -41,6 +138,9 @@ func LoadConfig() *Config {
 			Port: 7788,
 			Host: "0.0.0.0",
 		},
+		Providers: DefaultProvidersConfig(),
+		Runs:      RunsConfig{Retention: defaultRunRetention},
+		Trash:     TrashConfig{Retention: defaultTrashRetention},
 	}
 
 	// 尝试从配置文件加载
@@ -50,6 +150,40 @@ func LoadConfig() *Config {
 			fmt.Printf("Warning: failed to parse config file, using defaults: %v\n", err)
 			cfg = defaultConfig()
 		}
+		if cfg.Logging.Level == "" {
+			cfg.Logging.Level = "info"
+		}
+		if cfg.Logging.Output == "" {
+			cfg.Logging.Output = "stdout"
+		}
+		if cfg.Logging.Format == "" {
+			cfg.Logging.Format = "text"
+		}
+		if cfg.Logging.SlowQueryThreshold <= 0 {
+			cfg.Logging.SlowQueryThreshold = defaultSlowQueryThreshold
+		}
+		if cfg.Logging.MaxSize <= 0 {
+			cfg.Logging.MaxSize = defaultLogMaxSize
+		}
+		if cfg.Auth.SessionTTL <= 0 {
+			cfg.Auth.SessionTTL = 72 * time.Hour
+		}
+		defaults := DefaultProvidersConfig()
+		if cfg.Providers.Timeout <= 0 {
+			cfg.Providers.Timeout = defaults.Timeout
+		}
+		if cfg.Providers.RetryBaseDelay <= 0 {
+			cfg.Providers.RetryBaseDelay = defaults.RetryBaseDelay
+		}
+		if cfg.Providers.RetryMaxDelay <= 0 {
+			cfg.Providers.RetryMaxDelay = defaults.RetryMaxDelay
+		}
+		if cfg.Pricing.Currency == "" {
+			cfg.Pricing.Currency = "CNY"
+		}
+		if cfg.Evaluations.MaxConcurrency <= 0 {
+			cfg.Evaluations.MaxConcurrency = defaultEvaluationConcurrency
+		}
 	} else {
 		// 配置文件不存在，使用默认配置
 		cfg = defaultConfig()
@@ -103,8 +237,37 @@ func defaultConfig() *Config {
 			Password: "",
 		},
 		Logging: LoggingConfig{
-			Level:  "info",
-			Output: "stdout",
+			Level:              "info",
+			Output:             "stdout",
+			Format:             "text",
+			SlowQueryThreshold: defaultSlowQueryThreshold,
+			MaxSize:            defaultLogMaxSize,
+		},
+		Auth: AuthConfig{
+			SessionTTL: 72 * time.Hour,
+		},
+		Providers: DefaultProvidersConfig(),
+		Pricing: PricingConfig{
+			Currency: "CNY",
+		},
+		Runs: RunsConfig{
+			Retention: defaultRunRetention,
 		},
+		Evaluations: EvaluationsConfig{
+			MaxConcurrency: defaultEvaluationConcurrency,
+		},
+		Trash: TrashConfig{
+			Retention: defaultTrashRetention,
+		},
+	}
+}
+
+// DefaultProvidersConfig 模型服务商调用的默认配置
+func DefaultProvidersConfig() ProvidersConfig {
+	return ProvidersConfig{
+		Timeout:        120 * time.Second,
+		MaxRetries:     2,
+		RetryBaseDelay: 500 * time.Millisecond,
+		RetryMaxDelay:  30 * time.Second,
 	}
 }
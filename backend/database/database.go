@@ -2,15 +2,23 @@ package database
 
 import (
 	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"prompt-manager/config"
+	"prompt-manager/logging"
 	"prompt-manager/models"
+	"prompt-manager/services"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
@@ -21,9 +29,22 @@ func InitDB(cfg *config.Config) error {
 
 	switch cfg.Database.Type {
 	case "mysql":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			cfg.Database.User, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+		dsn := cfg.Database.DSN
+		if dsn == "" {
+			port := cfg.Database.Port
+			if port == 0 {
+				port = 3306
+			}
+			dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+				cfg.Database.User, cfg.Database.Password, cfg.Database.Host, port, cfg.Database.Name)
+		}
 		dialector = mysql.Open(dsn)
+	case "postgres":
+		dsn := cfg.Database.DSN
+		if dsn == "" {
+			dsn = postgresDSN(cfg.Database)
+		}
+		dialector = postgres.Open(dsn)
 	case "sqlite":
 		// 获取可执行文件所在目录
 		execPath, err := os.Executable()
@@ -39,28 +60,216 @@ func InitDB(cfg *config.Config) error {
 	}
 
 	DB, err = gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logging.NewGormLogger(cfg.Logging.SlowQueryThreshold),
+		TranslateError: true,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
+	return initialize(cfg)
+}
 
-	// 自动迁移表结构
-	if err := autoMigrate(); err != nil {
+// initialize 连接数据库后执行迁移，并补齐启动所需的数据
+func initialize(cfg *config.Config) error {
+	// 执行版本化迁移
+	if err := Migrate(); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
+	// 为尚未归属实体的提示词版本补建提示词实体
+	if err := BackfillPromptEntities(DB); err != nil {
+		return fmt.Errorf("failed to backfill prompt entities: %v", err)
+	}
+
+	// 首次启动时创建初始管理员
+	if err := ensureBootstrapAdmin(cfg.Auth); err != nil {
+		return fmt.Errorf("failed to create bootstrap admin: %v", err)
+	}
+
+	return nil
+}
+
+// postgresDSN 根据连接字段生成 postgres:// 形式的连接串，用户名、密码中的特殊字符会被转义
+func postgresDSN(cfg config.DatabaseConfig) string {
+	port := cfg.Port
+	if port == 0 {
+		port = 5432
+	}
+	query := url.Values{}
+	if cfg.SSLMode != "" {
+		query.Set("sslmode", cfg.SSLMode)
+	}
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// ensureBootstrapAdmin 用户表为空时按配置创建管理员账号
+func ensureBootstrapAdmin(cfg config.AuthConfig) error {
+	var count int64
+	if err := DB.Model(&models.User{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	admin := cfg.BootstrapAdmin
+	if admin.Username == "" || admin.Password == "" {
+		slog.Warn("No users exist, admin routes are unavailable until auth.bootstrap_admin is configured")
+		return nil
+	}
+
+	hash, err := services.HashPassword(admin.Password)
+	if err != nil {
+		return err
+	}
+	user := models.User{
+		Username:     admin.Username,
+		PasswordHash: hash,
+		Role:         services.RoleAdmin,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := DB.Create(&user).Error; err != nil {
+		return err
+	}
+	slog.Info("Bootstrap admin created", "username", admin.Username)
+	return nil
+}
+
+// dedupePromptVersions 同项目同名称下存在重复版本号时，保留最早的一条，
+// 其余记录追加构建元数据（如 1.0.1+3f2a9c1d），使 (project_id, name, version) 唯一索引可以建立。
+// 此时表结构尚未补齐，查询需跳过软删除条件，旧数据库中还没有 deleted_at 列
+func dedupePromptVersions() error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&models.Prompt{}) || migrator.HasIndex(&models.Prompt{}, "idx_prompt_version") {
+		return nil
+	}
+
+	var groups []struct {
+		ProjectID string
+		Name      string
+		Version   string
+	}
+	if err := DB.Unscoped().Model(&models.Prompt{}).Select("project_id, name, version").
+		Group("project_id, name, version").Having("COUNT(*) > 1").Scan(&groups).Error; err != nil {
+		return err
+	}
+
+	hasLabels := migrator.HasTable(&models.PromptLabel{})
+	for _, group := range groups {
+		var prompts []models.Prompt
+		if err := DB.Unscoped().Select("id", "version").Where("project_id = ? AND name = ? AND version = ?", group.ProjectID, group.Name, group.Version).
+			Order("created_at ASC").Find(&prompts).Error; err != nil {
+			return err
+		}
+		for _, prompt := range prompts[1:] {
+			suffix := strings.ReplaceAll(prompt.ID, "-", "")
+			if len(suffix) > 8 {
+				suffix = suffix[:8]
+			}
+			version := prompt.Version + "+" + suffix
+			if err := DB.Unscoped().Model(&models.Prompt{}).Where("id = ?", prompt.ID).UpdateColumn("version", version).Error; err != nil {
+				return err
+			}
+			if hasLabels {
+				if err := DB.Model(&models.PromptLabel{}).Where("prompt_id = ?", prompt.ID).UpdateColumn("version", version).Error; err != nil {
+					return err
+				}
+			}
+			slog.Warn("Renamed duplicate prompt version", "project_id", group.ProjectID, "prompt", group.Name, "version", group.Version, "renamed_to", version)
+		}
+	}
+	return nil
+}
+
+// BackfillPromptEntities 按 (project_id, name) 为没有 entity_id 的版本创建或关联提示词实体，
+// 新实体的描述、分类和标签取自该名称下最新的版本；导入数据后也会调用
+func BackfillPromptEntities(db *gorm.DB) error {
+	var groups []struct {
+		ProjectID string
+		Name      string
+	}
+	if err := db.Model(&models.Prompt{}).Select("project_id, name").
+		Where("entity_id IS NULL OR entity_id = ''").
+		Group("project_id, name").Scan(&groups).Error; err != nil {
+		return err
+	}
+
+	versionService := services.NewVersionService()
+	for _, group := range groups {
+		var entity models.PromptEntity
+		err := db.Where("project_id = ? AND name = ?", group.ProjectID, group.Name).First(&entity).Error
+		if err == gorm.ErrRecordNotFound {
+			var versions []models.Prompt
+			if err := db.Preload("Tags").Where("project_id = ? AND name = ?", group.ProjectID, group.Name).Find(&versions).Error; err != nil {
+				return err
+			}
+			numbers := make([]string, len(versions))
+			for i, v := range versions {
+				numbers[i] = v.Version
+			}
+			latest := versions[versionService.Latest(numbers)]
+			entity = models.PromptEntity{
+				ProjectID:   group.ProjectID,
+				Name:        group.Name,
+				Description: latest.Description,
+				Category:    latest.Category,
+				Tags:        latest.Tags,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if err := db.Create(&entity).Error; err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if err := db.Model(&models.Prompt{}).
+			Where("project_id = ? AND name = ? AND (entity_id IS NULL OR entity_id = '')", group.ProjectID, group.Name).
+			UpdateColumn("entity_id", entity.ID).Error; err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// autoMigrate 仅用于将引入版本化迁移之前的数据库补齐到基线表结构，之后的表结构变更需新增迁移文件
 func autoMigrate() error {
 	return DB.AutoMigrate(
 		&models.Project{},
 		&models.Prompt{},
+		&models.PromptEntity{},
 		&models.Tag{},
 		&models.Category{},
 		&models.PromptHistory{},
 		&models.Setting{},
+		&models.PromptLabel{},
+		&models.PromptLabelHistory{},
+		&models.APIKey{},
+		&models.User{},
+		&models.ProjectMember{},
+		&models.Session{},
+		&models.AuditLog{},
+		&models.CustomProvider{},
+		&models.TestRun{},
+		&models.Dataset{},
+		&models.DatasetRow{},
+		&models.Evaluation{},
+		&models.EvaluationResult{},
+		&models.PromptTestCase{},
+		&models.PromptCheckResult{},
+		&models.PromptReview{},
 	)
 }
 
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"prompt-manager/config"
+	"prompt-manager/models"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openBaselineDB 打开一个按基线版本表结构建立的 SQLite 数据库，并执行 seed 中的语句写入数据
+func openBaselineDB(t *testing.T, seed ...string) {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "legacy.db") + "?_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	DB = db
+	t.Cleanup(func() { CloseDB() })
+
+	schema, err := os.ReadFile(filepath.Join("testdata", "baseline_sqlite.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range append(splitStatements(string(schema)), seed...) {
+		if err := DB.Exec(statement).Error; err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+}
+
+func TestInitializeUpgradesBaselineDatabase(t *testing.T) {
+	openBaselineDB(t,
+		"INSERT INTO projects (id, name, created_at, updated_at) VALUES ('p1', 'demo', '2024-01-01 00:00:00', '2024-01-01 00:00:00')",
+		"INSERT INTO prompts (id, project_id, name, version, content, category, created_at) VALUES "+
+			"('v1', 'p1', 'greet', '1.0.0', 'Hi', 'c', '2024-01-01 00:00:00'), "+
+			"('v2', 'p1', 'greet', '1.0.1', 'Hello', 'c', '2024-01-02 00:00:00')",
+	)
+
+	if err := initialize(&config.Config{}); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	var prompts []models.Prompt
+	if err := DB.Order("version").Find(&prompts, "project_id = ?", "p1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("got %d prompts, want 2", len(prompts))
+	}
+	if prompts[0].EntityID == "" || prompts[0].EntityID != prompts[1].EntityID {
+		t.Errorf("versions not linked to one prompt entity: %q, %q", prompts[0].EntityID, prompts[1].EntityID)
+	}
+	if prompts[0].Status != "published" {
+		t.Errorf("status = %q, want published", prompts[0].Status)
+	}
+}
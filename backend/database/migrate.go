@@ -0,0 +1,194 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"prompt-manager/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFS 按数据库类型分目录存放的迁移 SQL，文件名形如 0002_prompt_search.sql
+// 修改表结构时需为 sqlite、mysql、postgres 各添加一个相同版本号的文件，已发布的迁移文件不再修改
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+// 多条语句组成的整体（如触发器）用这两行注释包裹，内部的分号不作为语句结束
+const (
+	statementBeginMarker = "-- statement begin"
+	statementEndMarker   = "-- statement end"
+)
+
+// migration 一个版本化迁移
+type migration struct {
+	Version int64
+	Name    string
+	SQL     string
+}
+
+// schemaMigration 已执行的迁移记录
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrate 按版本顺序执行尚未执行的迁移，每个迁移在一个事务中执行并记录到 schema_migrations
+// MySQL 的 DDL 会隐式提交事务，迁移中途失败时需要按错误信息手动修复后重新启动
+func Migrate() error {
+	migrations, err := loadMigrations(DB.Dialector.Name())
+	if err != nil {
+		return err
+	}
+
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&schemaMigration{}) {
+		legacy := migrator.HasTable(&models.Prompt{})
+		if err := migrator.CreateTable(&schemaMigration{}); err != nil {
+			return err
+		}
+		if legacy {
+			if err := adoptLegacySchema(); err != nil {
+				return fmt.Errorf("failed to adopt existing schema: %v", err)
+			}
+		}
+	}
+
+	var versions []int64
+	if err := DB.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return err
+	}
+	applied := make(map[int64]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if err := applyMigration(m); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %v", m.Version, m.Name, err)
+		}
+		slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+	}
+	return nil
+}
+
+// adoptLegacySchema 引入版本化迁移之前由 AutoMigrate 建立的数据库：
+// 处理重复版本号后用 AutoMigrate 补齐到基线表结构，并将基线记为已执行；
+// 已建立全文索引的数据库同时将全文索引迁移记为已执行
+func adoptLegacySchema() error {
+	// 去重需在 AutoMigrate 建立唯一索引之前执行，此时旧数据库中可能还没有后来新增的列
+	if err := dedupePromptVersions(); err != nil {
+		return err
+	}
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	adopted := []schemaMigration{{Version: 1, Name: "baseline"}}
+	if DB.Migrator().HasTable(searchTable) || DB.Migrator().HasIndex(&models.Prompt{}, searchIndexName) {
+		adopted = append(adopted, schemaMigration{Version: 2, Name: "prompt_search"})
+	}
+	for i := range adopted {
+		adopted[i].AppliedAt = time.Now()
+		slog.Info("Existing schema adopted as migration", "version", adopted[i].Version, "name", adopted[i].Name)
+	}
+	return DB.Create(&adopted).Error
+}
+
+func applyMigration(m migration) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range splitStatements(m.SQL) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// loadMigrations 读取指定数据库类型的迁移并按版本排序
+func loadMigrations(dialect string) ([]migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database type %s", dialect)
+	}
+
+	var migrations []migration
+	seen := map[int64]string{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".sql")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		prefix, label, ok := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(migrationFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{Version: version, Name: label, SQL: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements 按行尾分号拆分 SQL 语句，忽略注释行和空语句
+func splitStatements(sql string) []string {
+	var statements []string
+	var current []string
+	inBlock := false
+	flush := func() {
+		if statement := strings.TrimSpace(strings.Join(current, "\n")); statement != "" {
+			statements = append(statements, strings.TrimSuffix(statement, ";"))
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == statementBeginMarker:
+			flush()
+			inBlock = true
+		case trimmed == statementEndMarker:
+			// 触发器等语句以 END; 结尾，分号属于语句本身
+			if statement := strings.TrimSpace(strings.Join(current, "\n")); statement != "" {
+				statements = append(statements, statement)
+			}
+			current = nil
+			inBlock = false
+		case strings.HasPrefix(trimmed, "--") && !inBlock:
+		default:
+			current = append(current, line)
+			if !inBlock && strings.HasSuffix(trimmed, ";") {
+				flush()
+			}
+		}
+	}
+	flush()
+	return statements
+}
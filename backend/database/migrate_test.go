@@ -0,0 +1,85 @@
+package database
+
+import (
+	"prompt-manager/models"
+	"strings"
+	"testing"
+)
+
+func TestMigrateAdoptsLegacySchema(t *testing.T) {
+	openBaselineDB(t,
+		"INSERT INTO projects (id, name, created_at, updated_at) VALUES ('p1', 'demo', '2024-01-01 00:00:00', '2024-01-01 00:00:00')",
+		"INSERT INTO prompts (id, project_id, name, version, content, category, created_at) VALUES "+
+			"('a1b2c3d4-0000-0000-0000-000000000001', 'p1', 'greet', '1.0.0', 'Hi', 'c', '2024-01-01 00:00:00'), "+
+			"('e5f6a7b8-0000-0000-0000-000000000002', 'p1', 'greet', '1.0.0', 'Hello', 'c', '2024-01-02 00:00:00')",
+	)
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var versions []int64
+	if err := DB.Model(&schemaMigration{}).Order("version").Pluck("version", &versions).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 0001 由已有表结构接管，其余迁移依次执行
+	migrations, err := loadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != len(migrations) || versions[0] != 1 || versions[1] != 2 {
+		t.Fatalf("applied migrations = %v, want all %d migrations", versions, len(migrations))
+	}
+
+	// 重复的版本号中较早的一条保持不变，其余追加构建元数据
+	var prompts []models.Prompt
+	if err := DB.Order("created_at").Find(&prompts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 2 || prompts[0].Version != "1.0.0" || prompts[1].Version != "1.0.0+e5f6a7b8" {
+		t.Fatalf("versions after dedupe = %+v", prompts)
+	}
+	if !DB.Migrator().HasIndex(&models.Prompt{}, "idx_prompt_version") {
+		t.Error("unique version index not created")
+	}
+
+	// 0002 建立全文索引并回填已有的提示词
+	var indexed int64
+	if err := DB.Table(searchTable).Count(&indexed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 2 {
+		t.Errorf("search index rows = %d, want 2", indexed)
+	}
+
+	// 再次执行不会重复应用迁移
+	if err := Migrate(); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	var count int64
+	DB.Model(&schemaMigration{}).Count(&count)
+	if count != int64(len(migrations)) {
+		t.Errorf("schema_migrations rows = %d after rerun, want %d", count, len(migrations))
+	}
+}
+
+func TestSplitStatementsKeepsTriggerBodies(t *testing.T) {
+	sql := strings.Join([]string{
+		"-- comment",
+		"CREATE TABLE a (id int);",
+		"-- statement begin",
+		"CREATE TRIGGER t AFTER INSERT ON a BEGIN",
+		"  INSERT INTO b VALUES (new.id);",
+		"END;",
+		"-- statement end",
+		"INSERT INTO a VALUES (1);",
+	}, "\n")
+
+	statements := splitStatements(sql)
+	if len(statements) != 3 {
+		t.Fatalf("got %d statements: %q", len(statements), statements)
+	}
+	if statements[0] != "CREATE TABLE a (id int)" || !strings.HasSuffix(statements[1], "END;") || statements[2] != "INSERT INTO a VALUES (1)" {
+		t.Errorf("unexpected statements: %q", statements)
+	}
+}
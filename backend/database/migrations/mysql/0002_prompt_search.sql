@@ -0,0 +1,3 @@
+-- 提示词全文索引：ngram 分词的 FULLTEXT 索引，由 InnoDB 自动维护
+
+CREATE FULLTEXT INDEX `idx_prompts_fulltext` ON `prompts` (`name`, `description`, `content`) WITH PARSER ngram;
@@ -0,0 +1,4 @@
+-- 发布标签及其移动记录中的版本号与 prompts.version 等长，较长的预发布版本号和去重后的版本号才能被标记
+
+ALTER TABLE `prompt_labels` MODIFY `version` varchar(50) NOT NULL;
+ALTER TABLE `prompt_label_histories` MODIFY `from_version` varchar(50), MODIFY `to_version` varchar(50);
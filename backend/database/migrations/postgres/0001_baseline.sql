@@ -0,0 +1,421 @@
+-- 基线表结构：引入版本化迁移时由模型定义生成的完整表结构
+
+CREATE TABLE "projects" (
+  "id" varchar(36),
+  "name" varchar(100) NOT NULL,
+  "description" text,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "required_approvals" bigint DEFAULT 0,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_projects_deleted_at" ON "projects" ("deleted_at");
+
+CREATE TABLE "tags" (
+  "id" varchar(36),
+  "name" varchar(50) NOT NULL,
+  "color" varchar(7) DEFAULT '#3b82f6',
+  "created_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uni_tags_name" UNIQUE ("name")
+);
+CREATE INDEX IF NOT EXISTS "idx_tags_deleted_at" ON "tags" ("deleted_at");
+
+CREATE TABLE "project_tags" (
+  "tag_id" varchar(36),
+  "project_id" varchar(36),
+  PRIMARY KEY ("tag_id","project_id"),
+  CONSTRAINT "fk_project_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id"),
+  CONSTRAINT "fk_project_tags_project" FOREIGN KEY ("project_id") REFERENCES "projects"("id")
+);
+
+CREATE TABLE "prompts" (
+  "id" varchar(36),
+  "project_id" varchar(36) NOT NULL,
+  "entity_id" varchar(36),
+  "name" varchar(100) DEFAULT '',
+  "version" varchar(50) NOT NULL,
+  "content" text NOT NULL,
+  "description" text,
+  "category" varchar(50),
+  "variables" text,
+  "assertions" text,
+  "check_status" varchar(20) DEFAULT '',
+  "check_accepted" boolean DEFAULT false,
+  "review_status" varchar(20) DEFAULT '',
+  "base_id" varchar(36),
+  "status" varchar(20) DEFAULT 'published',
+  "deleted_at" timestamptz,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_projects_prompts" FOREIGN KEY ("project_id") REFERENCES "projects"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_prompts_category" ON "prompts" ("category");
+CREATE INDEX IF NOT EXISTS "idx_prompts_check_status" ON "prompts" ("check_status");
+CREATE INDEX IF NOT EXISTS "idx_prompts_deleted_at" ON "prompts" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_prompts_entity_id" ON "prompts" ("entity_id");
+CREATE INDEX IF NOT EXISTS "idx_prompts_name" ON "prompts" ("name");
+CREATE INDEX IF NOT EXISTS "idx_prompts_project_id" ON "prompts" ("project_id");
+CREATE INDEX IF NOT EXISTS "idx_prompts_review_status" ON "prompts" ("review_status");
+CREATE INDEX IF NOT EXISTS "idx_prompts_status" ON "prompts" ("status");
+CREATE INDEX IF NOT EXISTS "idx_prompts_version" ON "prompts" ("version");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_prompt_version" ON "prompts" ("project_id","name","version");
+
+CREATE TABLE "prompt_tags" (
+  "tag_id" varchar(36),
+  "prompt_id" varchar(36),
+  PRIMARY KEY ("tag_id","prompt_id"),
+  CONSTRAINT "fk_prompt_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id"),
+  CONSTRAINT "fk_prompt_tags_prompt" FOREIGN KEY ("prompt_id") REFERENCES "prompts"("id")
+);
+
+CREATE TABLE "prompt_entities" (
+  "id" varchar(36),
+  "project_id" varchar(36) NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "description" text,
+  "category" varchar(50),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_projects_prompt_entities" FOREIGN KEY ("project_id") REFERENCES "projects"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_entities_category" ON "prompt_entities" ("category");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_entity_name" ON "prompt_entities" ("project_id","name");
+
+CREATE TABLE "prompt_entity_tags" (
+  "prompt_entity_id" varchar(36),
+  "tag_id" varchar(36),
+  PRIMARY KEY ("prompt_entity_id","tag_id"),
+  CONSTRAINT "fk_prompt_entity_tags_prompt_entity" FOREIGN KEY ("prompt_entity_id") REFERENCES "prompt_entities"("id"),
+  CONSTRAINT "fk_prompt_entity_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE "categories" (
+  "id" varchar(36),
+  "name" varchar(50) NOT NULL,
+  "color" varchar(7) DEFAULT '#6366f1',
+  "created_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uni_categories_name" UNIQUE ("name")
+);
+CREATE INDEX IF NOT EXISTS "idx_categories_deleted_at" ON "categories" ("deleted_at");
+
+CREATE TABLE "prompt_histories" (
+  "id" varchar(36),
+  "prompt_id" varchar(36) NOT NULL,
+  "operation" varchar(20) NOT NULL,
+  "old_content" text,
+  "new_content" text,
+  "author" varchar(100),
+  "created_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_prompts_history" FOREIGN KEY ("prompt_id") REFERENCES "prompts"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_histories_prompt_id" ON "prompt_histories" ("prompt_id");
+
+CREATE TABLE "settings" (
+  "key" varchar(50),
+  "value" text,
+  "description" varchar(255),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("key")
+);
+
+CREATE TABLE "prompt_labels" (
+  "id" varchar(36),
+  "project_id" varchar(36) NOT NULL,
+  "prompt_name" varchar(100) NOT NULL,
+  "label" varchar(50) NOT NULL,
+  "prompt_id" varchar(36) NOT NULL,
+  "version" varchar(20) NOT NULL,
+  "updated_by" varchar(100),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_labels_prompt_id" ON "prompt_labels" ("prompt_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_prompt_label" ON "prompt_labels" ("project_id","prompt_name","label");
+
+CREATE TABLE "prompt_label_histories" (
+  "id" varchar(36),
+  "project_id" varchar(36) NOT NULL,
+  "prompt_name" varchar(100) NOT NULL,
+  "label" varchar(50) NOT NULL,
+  "operation" varchar(20) NOT NULL,
+  "from_prompt_id" varchar(36),
+  "from_version" varchar(20),
+  "to_prompt_id" varchar(36),
+  "to_version" varchar(20),
+  "actor" varchar(100),
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_label_histories_project_id" ON "prompt_label_histories" ("project_id");
+CREATE INDEX IF NOT EXISTS "idx_prompt_label_histories_prompt_name" ON "prompt_label_histories" ("prompt_name");
+
+CREATE TABLE "api_keys" (
+  "id" varchar(36),
+  "name" varchar(100) NOT NULL,
+  "key_prefix" varchar(16) NOT NULL,
+  "key_hash" varchar(64) NOT NULL,
+  "permission" varchar(10) NOT NULL,
+  "last_used_at" timestamptz,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+
+CREATE TABLE "api_key_projects" (
+  "api_key_id" varchar(36),
+  "project_id" varchar(36),
+  PRIMARY KEY ("api_key_id","project_id"),
+  CONSTRAINT "fk_api_key_projects_api_key" FOREIGN KEY ("api_key_id") REFERENCES "api_keys"("id"),
+  CONSTRAINT "fk_api_key_projects_project" FOREIGN KEY ("project_id") REFERENCES "projects"("id")
+);
+
+CREATE TABLE "users" (
+  "id" varchar(36),
+  "username" varchar(50) NOT NULL,
+  "password_hash" varchar(100) NOT NULL,
+  "role" varchar(10) NOT NULL,
+  "disabled" boolean NOT NULL DEFAULT false,
+  "last_login_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+
+CREATE TABLE "project_members" (
+  "project_id" varchar(36),
+  "user_id" varchar(36),
+  "role" varchar(10) NOT NULL,
+  "created_at" timestamptz,
+  PRIMARY KEY ("project_id","user_id"),
+  CONSTRAINT "fk_project_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_project_members_user_id" ON "project_members" ("user_id");
+
+CREATE TABLE "sessions" (
+  "id" varchar(36),
+  "token_hash" varchar(64) NOT NULL,
+  "user_id" varchar(36) NOT NULL,
+  "expires_at" timestamptz,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sessions_expires_at" ON "sessions" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_sessions_user_id" ON "sessions" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sessions_token_hash" ON "sessions" ("token_hash");
+
+CREATE TABLE "audit_logs" (
+  "id" varchar(36),
+  "actor" varchar(100),
+  "ip" varchar(64),
+  "method" varchar(10) NOT NULL,
+  "route" varchar(255) NOT NULL,
+  "path" varchar(512) NOT NULL,
+  "entity_type" varchar(50),
+  "entity_id" varchar(255),
+  "status_code" bigint,
+  "before" text,
+  "after" text,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor" ON "audit_logs" ("actor");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_entity_id" ON "audit_logs" ("entity_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_entity_type" ON "audit_logs" ("entity_type");
+
+CREATE TABLE "custom_providers" (
+  "id" varchar(36),
+  "name" varchar(50) NOT NULL,
+  "display_name" varchar(100),
+  "base_url" varchar(500) NOT NULL,
+  "auth_scheme" varchar(10) NOT NULL DEFAULT 'bearer',
+  "auth_header" varchar(100),
+  "default_model" varchar(100),
+  "models" text,
+  "extra_headers" text,
+  "stream_usage" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_custom_providers_name" ON "custom_providers" ("name");
+
+CREATE TABLE "test_runs" (
+  "id" varchar(36),
+  "kind" varchar(20) NOT NULL,
+  "project_id" varchar(36),
+  "prompt_id" varchar(36),
+  "rerun_of" varchar(36),
+  "provider" varchar(50) NOT NULL,
+  "model" varchar(100),
+  "temperature" decimal,
+  "top_p" decimal,
+  "max_tokens" bigint,
+  "stream" boolean,
+  "messages" text,
+  "response" text,
+  "status" varchar(20) NOT NULL,
+  "error" text,
+  "error_type" varchar(20),
+  "response_model" varchar(100),
+  "finish_reason" varchar(50),
+  "prompt_tokens" bigint,
+  "completion_tokens" bigint,
+  "total_tokens" bigint,
+  "time_to_first_token_ms" bigint,
+  "latency_ms" bigint,
+  "cost" decimal,
+  "currency" varchar(10),
+  "actor" varchar(100),
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_test_runs_created_at" ON "test_runs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_test_runs_kind" ON "test_runs" ("kind");
+CREATE INDEX IF NOT EXISTS "idx_test_runs_project_id" ON "test_runs" ("project_id");
+CREATE INDEX IF NOT EXISTS "idx_test_runs_prompt_id" ON "test_runs" ("prompt_id");
+CREATE INDEX IF NOT EXISTS "idx_test_runs_provider" ON "test_runs" ("provider");
+CREATE INDEX IF NOT EXISTS "idx_test_runs_status" ON "test_runs" ("status");
+
+CREATE TABLE "datasets" (
+  "id" varchar(36),
+  "project_id" varchar(36) NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "description" text,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_dataset_name" ON "datasets" ("project_id","name");
+
+CREATE TABLE "dataset_rows" (
+  "id" varchar(36),
+  "dataset_id" varchar(36) NOT NULL,
+  "position" bigint,
+  "variables" text,
+  "input" text,
+  "expected_output" text,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_datasets_rows" FOREIGN KEY ("dataset_id") REFERENCES "datasets"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dataset_rows_dataset_id" ON "dataset_rows" ("dataset_id");
+
+CREATE TABLE "evaluations" (
+  "id" varchar(36),
+  "project_id" varchar(36) NOT NULL,
+  "dataset_id" varchar(36) NOT NULL,
+  "prompt_id" varchar(36) NOT NULL,
+  "prompt_version" varchar(50),
+  "provider" varchar(50) NOT NULL,
+  "model" varchar(100),
+  "temperature" decimal,
+  "top_p" decimal,
+  "max_tokens" bigint,
+  "concurrency" bigint,
+  "status" varchar(20) NOT NULL,
+  "total_rows" bigint,
+  "completed_rows" bigint,
+  "failed_rows" bigint,
+  "total_tokens" bigint,
+  "cost" decimal,
+  "currency" varchar(10),
+  "error" text,
+  "actor" varchar(100),
+  "created_at" timestamptz,
+  "started_at" timestamptz,
+  "finished_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_evaluations_created_at" ON "evaluations" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_evaluations_dataset_id" ON "evaluations" ("dataset_id");
+CREATE INDEX IF NOT EXISTS "idx_evaluations_project_id" ON "evaluations" ("project_id");
+CREATE INDEX IF NOT EXISTS "idx_evaluations_prompt_id" ON "evaluations" ("prompt_id");
+CREATE INDEX IF NOT EXISTS "idx_evaluations_status" ON "evaluations" ("status");
+
+CREATE TABLE "evaluation_results" (
+  "id" varchar(36),
+  "evaluation_id" varchar(36) NOT NULL,
+  "row_id" varchar(36) NOT NULL,
+  "position" bigint,
+  "messages" text,
+  "output" text,
+  "expected_output" text,
+  "status" varchar(20) NOT NULL,
+  "error" text,
+  "error_type" varchar(20),
+  "finish_reason" varchar(50),
+  "prompt_tokens" bigint,
+  "completion_tokens" bigint,
+  "total_tokens" bigint,
+  "latency_ms" bigint,
+  "cost" decimal,
+  "currency" varchar(10),
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_evaluation_row" ON "evaluation_results" ("evaluation_id","row_id");
+
+CREATE TABLE "prompt_test_cases" (
+  "id" varchar(36),
+  "entity_id" varchar(36) NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "variables" text,
+  "messages" text,
+  "assertions" text,
+  "provider" varchar(50),
+  "model" varchar(100),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_test_cases_entity_id" ON "prompt_test_cases" ("entity_id");
+
+CREATE TABLE "prompt_check_results" (
+  "id" varchar(36),
+  "prompt_id" varchar(36) NOT NULL,
+  "test_case_id" varchar(36),
+  "position" bigint,
+  "name" varchar(100),
+  "status" varchar(20) NOT NULL,
+  "output" text,
+  "score" decimal,
+  "assertions" text,
+  "error" text,
+  "error_type" varchar(20),
+  "provider" varchar(50),
+  "model" varchar(100),
+  "prompt_tokens" bigint,
+  "completion_tokens" bigint,
+  "total_tokens" bigint,
+  "latency_ms" bigint,
+  "cost" decimal,
+  "currency" varchar(10),
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_check_results_prompt_id" ON "prompt_check_results" ("prompt_id");
+CREATE INDEX IF NOT EXISTS "idx_prompt_check_results_test_case_id" ON "prompt_check_results" ("test_case_id");
+
+CREATE TABLE "prompt_reviews" (
+  "id" varchar(36),
+  "prompt_id" varchar(36) NOT NULL,
+  "reviewer" varchar(100) NOT NULL,
+  "action" varchar(20) NOT NULL,
+  "comment" text,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prompt_reviews_prompt_id" ON "prompt_reviews" ("prompt_id");
@@ -0,0 +1 @@
+-- 提示词全文搜索：PostgreSQL 下搜索使用 ILIKE 匹配，不建立全文索引
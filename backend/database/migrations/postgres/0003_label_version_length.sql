@@ -0,0 +1,4 @@
+-- 发布标签及其移动记录中的版本号与 prompts.version 等长，较长的预发布版本号和去重后的版本号才能被标记
+
+ALTER TABLE "prompt_labels" ALTER COLUMN "version" TYPE varchar(50);
+ALTER TABLE "prompt_label_histories" ALTER COLUMN "from_version" TYPE varchar(50), ALTER COLUMN "to_version" TYPE varchar(50);
@@ -0,0 +1,25 @@
+-- 提示词全文索引：trigram 分词的 FTS5 表，由触发器在新增、修改、删除时同步
+
+CREATE VIRTUAL TABLE prompts_fts USING fts5(prompt_id UNINDEXED, name, description, content, tokenize='trigram');
+
+-- statement begin
+CREATE TRIGGER prompts_fts_insert AFTER INSERT ON prompts BEGIN
+  INSERT INTO prompts_fts (prompt_id, name, description, content) VALUES (new.id, new.name, new.description, new.content);
+END;
+-- statement end
+
+-- statement begin
+CREATE TRIGGER prompts_fts_update AFTER UPDATE OF name, description, content ON prompts BEGIN
+  DELETE FROM prompts_fts WHERE prompt_id = old.id;
+  INSERT INTO prompts_fts (prompt_id, name, description, content) VALUES (new.id, new.name, new.description, new.content);
+END;
+-- statement end
+
+-- statement begin
+CREATE TRIGGER prompts_fts_delete AFTER DELETE ON prompts BEGIN
+  DELETE FROM prompts_fts WHERE prompt_id = old.id;
+END;
+-- statement end
+
+-- 为已有的提示词版本建立索引
+INSERT INTO prompts_fts (prompt_id, name, description, content) SELECT id, name, description, content FROM prompts;
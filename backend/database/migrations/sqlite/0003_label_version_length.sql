@@ -0,0 +1 @@
+-- 发布标签及其移动记录中的版本号与 prompts.version 等长：SQLite 不限制 varchar 长度，无需修改表结构
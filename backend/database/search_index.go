@@ -0,0 +1,73 @@
+package database
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// searchTable SQLite 下提示词全文索引的 FTS5 虚拟表，MySQL 下为 FULLTEXT 索引 searchIndexName，
+// 均由迁移 0002_prompt_search 建立；PostgreSQL 不建立索引，搜索时使用 ILIKE 匹配
+const searchTable = "prompts_fts"
+
+const searchIndexName = "idx_prompts_fulltext"
+
+// likeEscaper 转义模糊匹配关键词中的通配符，使其按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// MatchSearchTerms 在提示词查询上加入关键词匹配条件，返回相关度表达式及其参数，多个关键词之间为“且”关系
+// SQLite 的 trigram 分词要求关键词至少 3 个字符，MySQL 的 ngram 分词至少 2 个字符，
+// 更短的关键词以及 PostgreSQL 下的关键词改用模糊匹配，不参与相关度计算
+func MatchSearchTerms(query *gorm.DB, terms []string) (*gorm.DB, string, []any) {
+	dialect := DB.Dialector.Name()
+	minLength, like := 0, "LIKE"
+	switch dialect {
+	case "sqlite":
+		minLength = 3
+	case "mysql":
+		minLength = 2
+	case "postgres":
+		like = "ILIKE"
+	}
+	// MySQL 的字符串字面量中反斜杠本身需要转义
+	escape := `'\'`
+	if dialect == "mysql" {
+		escape = `'\\'`
+	}
+	like += " ? ESCAPE " + escape
+
+	var indexed []string
+	for _, term := range terms {
+		if minLength > 0 && utf8.RuneCountInString(term) >= minLength {
+			indexed = append(indexed, term)
+			continue
+		}
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		query = query.Where("(prompts.name "+like+" OR prompts.description "+like+" OR prompts.content "+like+")", pattern, pattern, pattern)
+	}
+	if len(indexed) == 0 {
+		return query, "0", nil
+	}
+
+	switch dialect {
+	case "sqlite":
+		// 每个关键词作为短语匹配，双引号需转义；名称、描述、内容的权重依次降低
+		phrases := make([]string, len(indexed))
+		for i, term := range indexed {
+			phrases[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		}
+		query = query.Joins("JOIN "+searchTable+" ON "+searchTable+".prompt_id = prompts.id").
+			Where(searchTable+" MATCH ?", strings.Join(phrases, " "))
+		return query, "-bm25(" + searchTable + ", 0, 10, 5, 1)", nil
+	default:
+		// 布尔模式下 + 表示必须包含，短语中不能出现双引号
+		phrases := make([]string, len(indexed))
+		for i, term := range indexed {
+			phrases[i] = `+"` + strings.ReplaceAll(term, `"`, " ") + `"`
+		}
+		match := "MATCH (prompts.name, prompts.description, prompts.content) AGAINST (? IN BOOLEAN MODE)"
+		against := strings.Join(phrases, " ")
+		return query.Where(match, against), match, []any{against}
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"prompt-manager/models"
+	"testing"
+)
+
+// 模糊匹配的关键词中的 _、% 和反斜杠按字面匹配，不作为通配符
+func TestMatchSearchTermsEscapesWildcards(t *testing.T) {
+	openBaselineDB(t,
+		"INSERT INTO projects (id, name, created_at, updated_at) VALUES ('p1', 'demo', '2024-01-01 00:00:00', '2024-01-01 00:00:00')",
+		"INSERT INTO prompts (id, project_id, name, version, content, category, created_at) VALUES "+
+			"('v1', 'p1', 'a_b', '1.0.0', 'x', 'c', '2024-01-01 00:00:00'), "+
+			"('v2', 'p1', 'axb', '1.0.0', 'x', 'c', '2024-01-01 00:00:00'), "+
+			"('v3', 'p1', 'c%d', '1.0.0', 'x', 'c', '2024-01-01 00:00:00'), "+
+			"('v4', 'p1', 'e\\f', '1.0.0', 'x', 'c', '2024-01-01 00:00:00')",
+	)
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	// 两个字符的关键词在 SQLite 下走模糊匹配
+	cases := map[string]string{"a_": "a_b", "c%": "c%d", `e\`: `e\f`}
+	for term, want := range cases {
+		query, _, _ := MatchSearchTerms(DB.Model(&models.Prompt{}), []string{term})
+		var names []string
+		if err := query.Pluck("prompts.name", &names).Error; err != nil {
+			t.Fatalf("search %q: %v", term, err)
+		}
+		if len(names) != 1 || names[0] != want {
+			t.Errorf("search %q = %v, want [%s]", term, names, want)
+		}
+	}
+}
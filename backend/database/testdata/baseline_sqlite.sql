@@ -0,0 +1,14 @@
+-- 引入版本化迁移之前（基线版本）由 AutoMigrate 建立的 SQLite 表结构，用于测试旧数据库的升级
+CREATE TABLE `projects` (`id` varchar(36),`name` varchar(100) NOT NULL,`description` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE TABLE `tags` (`id` varchar(36),`name` varchar(50) NOT NULL,`color` varchar(7) DEFAULT "#3b82f6",`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `uni_tags_name` UNIQUE (`name`));
+CREATE TABLE `project_tags` (`tag_id` varchar(36),`project_id` varchar(36),PRIMARY KEY (`tag_id`,`project_id`),CONSTRAINT `fk_project_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`),CONSTRAINT `fk_project_tags_project` FOREIGN KEY (`project_id`) REFERENCES `projects`(`id`));
+CREATE TABLE `prompts` (`id` varchar(36),`project_id` varchar(36) NOT NULL,`name` varchar(100) DEFAULT "",`version` varchar(20) NOT NULL,`content` text NOT NULL,`description` text,`category` varchar(50),`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_projects_prompts` FOREIGN KEY (`project_id`) REFERENCES `projects`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_prompts_category` ON `prompts`(`category`);
+CREATE INDEX `idx_prompts_version` ON `prompts`(`version`);
+CREATE INDEX `idx_prompts_name` ON `prompts`(`name`);
+CREATE INDEX `idx_prompts_project_id` ON `prompts`(`project_id`);
+CREATE TABLE `prompt_tags` (`tag_id` varchar(36),`prompt_id` varchar(36),PRIMARY KEY (`tag_id`,`prompt_id`),CONSTRAINT `fk_prompt_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`),CONSTRAINT `fk_prompt_tags_prompt` FOREIGN KEY (`prompt_id`) REFERENCES `prompts`(`id`));
+CREATE TABLE `categories` (`id` varchar(36),`name` varchar(50) NOT NULL,`color` varchar(7) DEFAULT "#6366f1",`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `uni_categories_name` UNIQUE (`name`));
+CREATE TABLE `prompt_histories` (`id` varchar(36),`prompt_id` varchar(36) NOT NULL,`operation` varchar(20) NOT NULL,`old_content` text,`new_content` text,`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_prompts_history` FOREIGN KEY (`prompt_id`) REFERENCES `prompts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_prompt_histories_prompt_id` ON `prompt_histories`(`prompt_id`);
+CREATE TABLE `settings` (`key` varchar(50),`value` text,`description` varchar(255),`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`key`));
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type APIKeyHandler struct{}
+
+func NewAPIKeyHandler() *APIKeyHandler {
+	return &APIKeyHandler{}
+}
+
+// GetAPIKeys 获取 API Key 列表（不包含明文）
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	query := database.DB.Preload("Projects", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name")
+	})
+	if projectID := c.Query("project_id"); projectID != "" {
+		query = query.Joins("JOIN api_key_projects ON api_key_projects.api_key_id = api_keys.id").
+			Where("api_key_projects.project_id = ?", projectID)
+	}
+	if err := query.Order("created_at DESC").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  keys,
+		"total": len(keys),
+	})
+}
+
+// CreateAPIKey 创建 API Key，明文只在创建时返回一次
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req struct {
+		Name       string   `json:"name" binding:"required"`
+		Permission string   `json:"permission" binding:"required,oneof=read write"`
+		ProjectIDs []string `json:"project_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var projects []models.Project
+	if err := database.DB.Where("id IN ?", req.ProjectIDs).Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+	if len(projects) != len(req.ProjectIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ids"})
+		return
+	}
+
+	plain, prefix, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	key := models.APIKey{
+		Name:       req.Name,
+		KeyPrefix:  prefix,
+		KeyHash:    hash,
+		Permission: req.Permission,
+		CreatedAt:  time.Now(),
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Omit("Projects").Create(&key).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	if err := tx.Model(&key).Association("Projects").Append(&projects); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate projects"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": key,
+		"key":     plain,
+	})
+}
+
+// RevokeAPIKey 吊销 API Key
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	var key models.APIKey
+	if err := database.DB.First(&key, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API key"})
+		return
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&key).Update("revoked_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
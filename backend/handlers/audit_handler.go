@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAuditExportRows 单次导出的最大条数
+const maxAuditExportRows = 50000
+
+type AuditHandler struct{}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// GetAuditLogs 分页查询审计日志
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	page, pageSize, offset := parsePagination(c)
+	query := h.auditQuery(c)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit logs"})
+		return
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      logs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ExportAuditLogs 按相同的筛选条件导出 CSV
+func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
+	var logs []models.AuditLog
+	if err := h.auditQuery(c).Order("created_at DESC").Limit(maxAuditExportRows).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	filename := fmt.Sprintf("audit_logs_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	// 写入 BOM，便于 Excel 正确识别中文
+	c.Writer.Write([]byte("\xEF\xBB\xBF"))
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"time", "actor", "ip", "method", "route", "path", "entity_type", "entity_id", "status_code", "before", "after"})
+	for _, entry := range logs {
+		writer.Write([]string{
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.Actor,
+			entry.IP,
+			entry.Method,
+			entry.Route,
+			entry.Path,
+			entry.EntityType,
+			entry.EntityID,
+			strconv.Itoa(entry.StatusCode),
+			entry.Before,
+			entry.After,
+		})
+	}
+	writer.Flush()
+}
+
+// auditQuery 构建审计日志查询，支持按操作人、实体、方法、状态码和时间范围筛选
+func (h *AuditHandler) auditQuery(c *gin.Context) *gorm.DB {
+	query := database.DB.Model(&models.AuditLog{})
+
+	if actor := c.Query("actor"); actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if method := c.Query("method"); method != "" {
+		query = query.Where("method = ?", method)
+	}
+	if route := c.Query("route"); route != "" {
+		query = query.Where("route LIKE ?", "%"+route+"%")
+	}
+	if status := c.Query("status_code"); status != "" {
+		query = query.Where("status_code = ?", status)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("created_at >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("created_at <= ?", endDate)
+	}
+	return query
+}
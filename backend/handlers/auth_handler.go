@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/middleware"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	sessionTTL time.Duration
+}
+
+func NewAuthHandler(sessionTTL time.Duration) *AuthHandler {
+	return &AuthHandler{sessionTTL: sessionTTL}
+}
+
+// Login 用户名密码登录，返回会话令牌
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("username = ?", req.Username).First(&user).Error; err != nil ||
+		user.Disabled || !services.CheckPassword(user.PasswordHash, req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+
+	plain, hash, err := services.GenerateSessionToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	now := time.Now()
+	session := models.Session{
+		TokenHash: hash,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(h.sessionTTL),
+		CreatedAt: now,
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	database.DB.Model(&user).UpdateColumn("last_login_at", now)
+	// 顺便清理该用户已过期的会话
+	database.DB.Where("user_id = ? AND expires_at < ?", user.ID, now).Delete(&models.Session{})
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      plain,
+		"expires_at": session.ExpiresAt,
+		"user":       user,
+	})
+}
+
+// Logout 注销当前会话
+func (h *AuthHandler) Logout(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(token, services.SessionTokenPrefix) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session token required"})
+		return
+	}
+
+	if err := database.DB.Where("token_hash = ?", services.HashToken(strings.TrimSpace(token))).Delete(&models.Session{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// Me 获取当前登录用户及其项目角色
+func (h *AuthHandler) Me(c *gin.Context) {
+	user := currentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not logged in"})
+		return
+	}
+
+	var memberships []models.ProjectMember
+	if err := database.DB.Where("user_id = ?", user.ID).Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":     user,
+		"projects": memberships,
+	})
+}
+
+// currentUser 获取通过会话认证的当前用户，未登录时返回 nil
+func currentUser(c *gin.Context) *models.User {
+	if value, ok := c.Get(middleware.UserContextKey); ok {
+		if user, ok := value.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "prompt-manager/database"
     "prompt-manager/models"
@@ -14,14 +15,32 @@ type CategoryHandler struct{}
 
 func NewCategoryHandler() *CategoryHandler { return &CategoryHandler{} }
 
-// GetCategories 获取所有分类
+// categoryListSpec 分类列表可排序、可选择的字段
+var categoryListSpec = &listSpec{
+    table:       "categories",
+    sortable:    []string{"name", "created_at"},
+    defaultSort: "created_at",
+    fields:      map[string]string{"id": "id", "name": "name", "color": "color", "created_at": "created_at"},
+}
+
+// GetCategories 获取分类列表，支持分页、排序和字段选择
 func (h *CategoryHandler) GetCategories(c *gin.Context) {
+    q, ok := parseListQuery(c, categoryListSpec)
+    if !ok {
+        return
+    }
+    query := database.DB.Model(&models.Category{})
+    var total int64
+    if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count categories"})
+        return
+    }
     var categories []models.Category
-    if err := database.DB.Find(&categories).Error; err != nil {
+    if err := q.apply(query).Find(&categories).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"data": categories, "total": len(categories)})
+    q.respond(c, categories, total)
 }
 
 // GetCategory 获取单个分类
@@ -54,7 +73,20 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
         Color:     func() string { if req.Color != "" { return req.Color }; return "#6366f1" }(),
         CreatedAt: time.Now(),
     }
+    // 同名分类在回收站中时直接恢复
+    var trashed models.Category
+    if restored, err := restoreTrashedByName(&trashed, category.Name, category.Color); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore category"})
+        return
+    } else if restored {
+        c.JSON(http.StatusCreated, trashed)
+        return
+    }
     if err := database.DB.Create(&category).Error; err != nil {
+        if errors.Is(err, gorm.ErrDuplicatedKey) {
+            c.JSON(http.StatusConflict, gin.H{"error": "Category name already exists"})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
         return
     }
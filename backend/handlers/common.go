@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"prompt-manager/database"
+	"prompt-manager/middleware"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestActor 获取当前操作人：登录用户记录用户名，使用 API Key 时记录 Key 名称，
+// 匿名请求优先使用请求中声明的 operator，再退回到客户端 IP
+func requestActor(c *gin.Context, operator string) string {
+	if actor := middleware.CurrentActor(c); actor != "" {
+		return actor
+	}
+	if operator != "" {
+		return operator
+	}
+	return c.ClientIP()
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parsePagination 解析 page、page_size 参数，返回页码、每页数量和偏移量
+func parsePagination(c *gin.Context) (page, pageSize, offset int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize, (page - 1) * pageSize
+}
+
+// deleteJoinRows 删除多对多关联表中 column 等于 value 的记录，value 为切片时按 IN 匹配
+// 表名和列名由 GORM 按数据库方言转义
+func deleteJoinRows(tx *gorm.DB, table, column string, value any) error {
+	return tx.Table(table).Where(map[string]any{column: value}).Delete(map[string]any{}).Error
+}
+
+// restoreTrashedByName 名称唯一的标签、分类在回收站中存在同名记录时将其恢复到 dest，
+// color 非空时一并更新颜色。没有同名的已删除记录时返回 false
+func restoreTrashedByName(dest any, name, color string) (bool, error) {
+	err := database.DB.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", name).First(dest).Error
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	updates := map[string]any{"deleted_at": nil}
+	if color != "" {
+		updates["color"] = color
+	}
+	if err := database.DB.Unscoped().Model(dest).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	return true, database.DB.Where("name = ?", name).First(dest).Error
+}
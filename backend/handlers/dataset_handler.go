@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDatasetRows 单个数据集的最大行数
+const maxDatasetRows = 10000
+
+type DatasetHandler struct{}
+
+func NewDatasetHandler() *DatasetHandler {
+	return &DatasetHandler{}
+}
+
+// datasetRowRequest 新增或修改数据集行的请求
+type datasetRowRequest struct {
+	Variables      models.VariableValues `json:"variables"`
+	Input          string                `json:"input"`
+	ExpectedOutput string                `json:"expected_output"`
+}
+
+// datasetItem 数据集及其行数
+type datasetItem struct {
+	models.Dataset
+	RowCount int64 `json:"row_count"`
+}
+
+// GetDatasets 获取项目下的数据集列表
+func (h *DatasetHandler) GetDatasets(c *gin.Context) {
+	var datasets []models.Dataset
+	if err := database.DB.Where("project_id = ?", c.Param("id")).Order("name ASC").Find(&datasets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasets"})
+		return
+	}
+
+	var counts []struct {
+		DatasetID string
+		Count     int64
+	}
+	if err := database.DB.Model(&models.DatasetRow{}).Select("dataset_id, COUNT(*) AS count").
+		Joins("JOIN datasets ON datasets.id = dataset_rows.dataset_id").
+		Where("datasets.project_id = ?", c.Param("id")).
+		Group("dataset_id").Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count dataset rows"})
+		return
+	}
+	rowCounts := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		rowCounts[count.DatasetID] = count.Count
+	}
+
+	items := make([]datasetItem, len(datasets))
+	for i, dataset := range datasets {
+		items[i] = datasetItem{Dataset: dataset, RowCount: rowCounts[dataset.ID]}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "total": len(items)})
+}
+
+// CreateDataset 在项目下创建数据集，可同时传入数据行
+func (h *DatasetHandler) CreateDataset(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var req struct {
+		Name        string              `json:"name" binding:"required"`
+		Description string              `json:"description"`
+		Rows        []datasetRowRequest `json:"rows"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if len(req.Rows) > maxDatasetRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many rows"})
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.Select("id").First(&project, "id = ?", projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+
+	now := time.Now()
+	dataset := models.Dataset{
+		ProjectID:   projectID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&dataset).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Dataset name already exists in this project"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create dataset"})
+		return
+	}
+	if _, err := appendDatasetRows(tx, dataset.ID, 0, req.Rows); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create dataset rows"})
+		return
+	}
+	tx.Commit()
+
+	loaded, ok := loadDataset(c, dataset.ID, true)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, loaded)
+}
+
+// GetDataset 获取数据集及其全部数据行
+func (h *DatasetHandler) GetDataset(c *gin.Context) {
+	dataset, ok := loadDataset(c, c.Param("id"), true)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dataset)
+}
+
+// UpdateDataset 修改数据集名称和描述
+func (h *DatasetHandler) UpdateDataset(c *gin.Context) {
+	dataset, ok := loadDataset(c, c.Param("id"), false)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        string  `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]any{"updated_at": time.Now()}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if err := database.DB.Model(dataset).Updates(updates).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Dataset name already exists in this project"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dataset"})
+		return
+	}
+
+	dataset, ok = loadDataset(c, dataset.ID, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dataset)
+}
+
+// DeleteDataset 删除数据集及其数据行和评测结果，存在运行中的评测时不允许删除
+func (h *DatasetHandler) DeleteDataset(c *gin.Context) {
+	dataset, ok := loadDataset(c, c.Param("id"), false)
+	if !ok {
+		return
+	}
+
+	var running int64
+	if err := database.DB.Model(&models.Evaluation{}).
+		Where("dataset_id = ? AND status IN ?", dataset.ID, []string{evaluationStatusPending, evaluationStatusRunning}).
+		Count(&running).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check evaluations"})
+		return
+	}
+	if running > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dataset has running evaluations"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := deleteDatasets(tx, []string{dataset.ID}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete dataset"})
+		return
+	}
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Dataset deleted successfully"})
+}
+
+// AddDatasetRows 在数据集末尾追加数据行
+func (h *DatasetHandler) AddDatasetRows(c *gin.Context) {
+	dataset, ok := loadDataset(c, c.Param("id"), false)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Rows []datasetRowRequest `json:"rows" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var stats struct {
+		Count       int64
+		MaxPosition int
+	}
+	if err := database.DB.Model(&models.DatasetRow{}).Select("COUNT(*) AS count, COALESCE(MAX(position), 0) AS max_position").
+		Where("dataset_id = ?", dataset.ID).Scan(&stats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count dataset rows"})
+		return
+	}
+	if stats.Count+int64(len(req.Rows)) > maxDatasetRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many rows"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	rows, err := appendDatasetRows(tx, dataset.ID, stats.MaxPosition, req.Rows)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create dataset rows"})
+		return
+	}
+	if err := tx.Model(dataset).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dataset"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{"data": rows, "total": len(rows)})
+}
+
+// UpdateDatasetRow 修改单行的变量、输入和期望输出
+func (h *DatasetHandler) UpdateDatasetRow(c *gin.Context) {
+	row, ok := loadDatasetRow(c)
+	if !ok {
+		return
+	}
+
+	var req datasetRowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Variables == nil {
+		req.Variables = models.VariableValues{}
+	}
+
+	row.Variables = req.Variables
+	row.Input = req.Input
+	row.ExpectedOutput = req.ExpectedOutput
+	row.UpdatedAt = time.Now()
+	if err := database.DB.Save(row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dataset row"})
+		return
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// DeleteDatasetRow 删除单行，已有的评测结果保留
+func (h *DatasetHandler) DeleteDatasetRow(c *gin.Context) {
+	row, ok := loadDatasetRow(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete dataset row"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Dataset row deleted successfully"})
+}
+
+// loadDataset 按 ID 加载数据集，withRows 为 true 时按顺序加载数据行，失败时直接写入错误响应
+func loadDataset(c *gin.Context, id string, withRows bool) (*models.Dataset, bool) {
+	query := database.DB
+	if withRows {
+		query = query.Preload("Rows", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		})
+	}
+
+	var dataset models.Dataset
+	if err := query.First(&dataset, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dataset not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset"})
+		return nil, false
+	}
+	if withRows && dataset.Rows == nil {
+		dataset.Rows = []models.DatasetRow{}
+	}
+	return &dataset, true
+}
+
+// loadDatasetRow 加载路由中 :id 数据集下的 :row_id 行
+func loadDatasetRow(c *gin.Context) (*models.DatasetRow, bool) {
+	var row models.DatasetRow
+	if err := database.DB.Where("id = ? AND dataset_id = ?", c.Param("row_id"), c.Param("id")).First(&row).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dataset row not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset row"})
+		return nil, false
+	}
+	return &row, true
+}
+
+// appendDatasetRows 从 after 之后的位置依次写入数据行
+func appendDatasetRows(tx *gorm.DB, datasetID string, after int, requests []datasetRowRequest) ([]models.DatasetRow, error) {
+	rows := make([]models.DatasetRow, len(requests))
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	now := time.Now()
+	for i, req := range requests {
+		if req.Variables == nil {
+			req.Variables = models.VariableValues{}
+		}
+		rows[i] = models.DatasetRow{
+			DatasetID:      datasetID,
+			Position:       after + i + 1,
+			Variables:      req.Variables,
+			Input:          req.Input,
+			ExpectedOutput: req.ExpectedOutput,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+	}
+	if err := tx.CreateInBatches(&rows, 500).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// deleteDatasets 删除数据集，以及它们的数据行、评测任务和评测结果
+func deleteDatasets(tx *gorm.DB, datasetIDs []string) error {
+	if len(datasetIDs) == 0 {
+		return nil
+	}
+	evaluationIDs := tx.Model(&models.Evaluation{}).Select("id").Where("dataset_id IN ?", datasetIDs)
+	if err := tx.Where("evaluation_id IN (?)", evaluationIDs).Delete(&models.EvaluationResult{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("dataset_id IN ?", datasetIDs).Delete(&models.Evaluation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("dataset_id IN ?", datasetIDs).Delete(&models.DatasetRow{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", datasetIDs).Delete(&models.Dataset{}).Error
+}
@@ -0,0 +1,540 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 评测任务的状态
+const (
+	evaluationStatusPending   = "pending"
+	evaluationStatusRunning   = "running"
+	evaluationStatusCompleted = "completed"
+	evaluationStatusFailed    = "failed"
+	evaluationStatusCanceled  = "canceled"
+)
+
+// 评测结果的状态
+const (
+	resultStatusSuccess = "success"
+	resultStatusError   = "error"
+)
+
+// defaultEvaluationConcurrency 请求未指定 concurrency 时的并发数
+const defaultEvaluationConcurrency = 4
+
+// maxCompareEvaluations 单次对比的最大评测任务数
+const maxCompareEvaluations = 10
+
+// runningEvaluations 运行中评测任务的取消函数
+var (
+	runningEvaluations   = map[string]context.CancelFunc{}
+	runningEvaluationsMu sync.Mutex
+)
+
+type EvaluationHandler struct {
+	templateService *services.TemplateService
+	maxConcurrency  int
+}
+
+func NewEvaluationHandler(maxConcurrency int) *EvaluationHandler {
+	return &EvaluationHandler{
+		templateService: services.NewTemplateService(),
+		maxConcurrency:  maxConcurrency,
+	}
+}
+
+// evaluationCall 单行评测所需的模型调用参数，所有行共用
+type evaluationCall struct {
+	creds   *providerCredentials
+	options services.ChatOptions
+	prompt  *models.Prompt
+}
+
+// compareRow 对比结果中的一行，Results 按评测任务 ID 索引，未运行该行的任务为 null
+type compareRow struct {
+	RowID          string                              `json:"row_id"`
+	Position       int                                 `json:"position"`
+	Variables      models.VariableValues               `json:"variables"`
+	Input          string                              `json:"input"`
+	ExpectedOutput string                              `json:"expected_output"`
+	Results        map[string]*models.EvaluationResult `json:"results"`
+}
+
+// CreateEvaluation 使用指定的提示词版本对数据集逐行调用模型，任务在后台运行，立即返回 202
+func (h *EvaluationHandler) CreateEvaluation(c *gin.Context) {
+	dataset, ok := loadDataset(c, c.Param("id"), true)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PromptID    string   `json:"prompt_id" binding:"required"`
+		Provider    string   `json:"provider"`
+		Model       string   `json:"model"`
+		Temperature *float64 `json:"temperature"`
+		TopP        *float64 `json:"top_p"`
+		MaxTokens   int      `json:"max_tokens"`
+		Concurrency int      `json:"concurrency"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(dataset.Rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dataset has no rows"})
+		return
+	}
+
+	var prompt models.Prompt
+	if err := database.DB.First(&prompt, "id = ?", req.PromptID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+		return
+	}
+	if prompt.ProjectID != dataset.ProjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt and dataset belong to different projects"})
+		return
+	}
+
+	creds, ok := resolveProviderCredentials(c, req.Provider, req.Model)
+	if !ok {
+		return
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = min(defaultEvaluationConcurrency, h.maxConcurrency)
+	}
+	if concurrency > h.maxConcurrency {
+		concurrency = h.maxConcurrency
+	}
+
+	evaluation := models.Evaluation{
+		ProjectID:     dataset.ProjectID,
+		DatasetID:     dataset.ID,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		Provider:      string(creds.Provider),
+		Model:         creds.Model,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		MaxTokens:     req.MaxTokens,
+		Concurrency:   concurrency,
+		Status:        evaluationStatusPending,
+		TotalRows:     len(dataset.Rows),
+		Actor:         requestActor(c, ""),
+		CreatedAt:     time.Now(),
+	}
+	if err := database.DB.Create(&evaluation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create evaluation"})
+		return
+	}
+
+	call := &evaluationCall{
+		creds: creds,
+		options: services.ChatOptions{
+			Model:       creds.Model,
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			MaxTokens:   req.MaxTokens,
+		},
+		prompt: &prompt,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runningEvaluationsMu.Lock()
+	runningEvaluations[evaluation.ID] = cancel
+	runningEvaluationsMu.Unlock()
+
+	go h.runEvaluation(ctx, evaluation, dataset.Rows, call)
+
+	c.JSON(http.StatusAccepted, evaluation)
+}
+
+// GetEvaluations 获取数据集的评测任务列表
+func (h *EvaluationHandler) GetEvaluations(c *gin.Context) {
+	query := database.DB.Model(&models.Evaluation{}).Where("dataset_id = ?", c.Param("id"))
+	if promptID := c.Query("prompt_id"); promptID != "" {
+		query = query.Where("prompt_id = ?", promptID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	page, pageSize, offset := parsePagination(c)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count evaluations"})
+		return
+	}
+
+	var evaluations []models.Evaluation
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&evaluations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch evaluations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      evaluations,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetEvaluation 获取评测任务的进度和汇总
+func (h *EvaluationHandler) GetEvaluation(c *gin.Context) {
+	evaluation, ok := loadEvaluation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, evaluation)
+}
+
+// GetEvaluationResults 按数据行顺序分页获取评测结果
+func (h *EvaluationHandler) GetEvaluationResults(c *gin.Context) {
+	evaluation, ok := loadEvaluation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	query := database.DB.Model(&models.EvaluationResult{}).Where("evaluation_id = ?", evaluation.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	page, pageSize, offset := parsePagination(c)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count evaluation results"})
+		return
+	}
+
+	var results []models.EvaluationResult
+	if err := query.Order("position ASC").Offset(offset).Limit(pageSize).Find(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch evaluation results"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      results,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// CancelEvaluation 取消运行中的评测任务，已完成的行保留结果
+func (h *EvaluationHandler) CancelEvaluation(c *gin.Context) {
+	evaluation, ok := loadEvaluation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	runningEvaluationsMu.Lock()
+	cancel, running := runningEvaluations[evaluation.ID]
+	runningEvaluationsMu.Unlock()
+	if !running {
+		c.JSON(http.StatusConflict, gin.H{"error": "Evaluation is not running"})
+		return
+	}
+	cancel()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Evaluation canceled"})
+}
+
+// DeleteEvaluation 删除评测任务及其结果，运行中的任务需先取消
+func (h *EvaluationHandler) DeleteEvaluation(c *gin.Context) {
+	evaluation, ok := loadEvaluation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if evaluation.Status == evaluationStatusPending || evaluation.Status == evaluationStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "Evaluation is still running"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("evaluation_id = ?", evaluation.ID).Delete(&models.EvaluationResult{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete evaluation results"})
+		return
+	}
+	if err := tx.Delete(evaluation).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete evaluation"})
+		return
+	}
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Evaluation deleted successfully"})
+}
+
+// CompareEvaluations 按数据行并排对比同一数据集上多个评测任务（通常是不同提示词版本）的输出
+// evaluations 为逗号分隔的评测任务 ID
+func (h *EvaluationHandler) CompareEvaluations(c *gin.Context) {
+	dataset, ok := loadDataset(c, c.Param("id"), true)
+	if !ok {
+		return
+	}
+
+	var ids []string
+	for _, id := range strings.Split(c.Query("evaluations"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "evaluations is required"})
+		return
+	}
+	if len(ids) > maxCompareEvaluations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many evaluations to compare"})
+		return
+	}
+
+	var evaluations []models.Evaluation
+	if err := database.DB.Where("id IN ? AND dataset_id = ?", ids, dataset.ID).Find(&evaluations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch evaluations"})
+		return
+	}
+	if len(evaluations) != len(ids) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid evaluation ids for this dataset"})
+		return
+	}
+	// 按请求中的顺序返回
+	order := make(map[string]int, len(ids))
+	for i, id := range ids {
+		order[id] = i
+	}
+	ordered := make([]models.Evaluation, len(evaluations))
+	for _, evaluation := range evaluations {
+		ordered[order[evaluation.ID]] = evaluation
+	}
+
+	var results []models.EvaluationResult
+	if err := database.DB.Where("evaluation_id IN ?", ids).Find(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch evaluation results"})
+		return
+	}
+	byRow := make(map[string]map[string]*models.EvaluationResult)
+	for i := range results {
+		result := &results[i]
+		if byRow[result.RowID] == nil {
+			byRow[result.RowID] = make(map[string]*models.EvaluationResult)
+		}
+		byRow[result.RowID][result.EvaluationID] = result
+	}
+
+	rows := make([]compareRow, len(dataset.Rows))
+	for i, row := range dataset.Rows {
+		rows[i] = compareRow{
+			RowID:          row.ID,
+			Position:       row.Position,
+			Variables:      row.Variables,
+			Input:          row.Input,
+			ExpectedOutput: row.ExpectedOutput,
+			Results:        make(map[string]*models.EvaluationResult, len(ids)),
+		}
+		for _, id := range ids {
+			rows[i].Results[id] = byRow[row.ID][id]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"evaluations": ordered,
+		"rows":        rows,
+	})
+}
+
+// runEvaluation 以 evaluation.Concurrency 个 worker 并发调用模型，结果由当前 goroutine 逐条写入，
+// 避免 SQLite 下的并发写入冲突
+func (h *EvaluationHandler) runEvaluation(ctx context.Context, evaluation models.Evaluation, rows []models.DatasetRow, call *evaluationCall) {
+	defer func() {
+		runningEvaluationsMu.Lock()
+		runningEvaluations[evaluation.ID]()
+		delete(runningEvaluations, evaluation.ID)
+		runningEvaluationsMu.Unlock()
+	}()
+
+	startedAt := time.Now()
+	database.DB.Model(&evaluation).Updates(map[string]any{"status": evaluationStatusRunning, "started_at": startedAt})
+
+	jobs := make(chan models.DatasetRow)
+	results := make(chan *models.EvaluationResult)
+	var wg sync.WaitGroup
+	for range evaluation.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				if result := h.evaluateRow(ctx, evaluation.ID, row, call); result != nil {
+					results <- result
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, row := range rows {
+			select {
+			case jobs <- row:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		completed, failed, totalTokens int
+		cost                           *float64
+		currency, firstError           string
+	)
+	for result := range results {
+		if err := database.DB.Create(result).Error; err != nil {
+			slog.Error("Failed to save evaluation result", "evaluation_id", result.EvaluationID, "row_id", result.RowID, "error", err)
+		}
+
+		completed++
+		if result.Status == resultStatusError {
+			failed++
+			if firstError == "" {
+				firstError = result.Error
+			}
+		}
+		totalTokens += result.TotalTokens
+		if result.Cost != nil {
+			sum := *result.Cost
+			if cost != nil {
+				sum += *cost
+			}
+			cost = &sum
+			currency = result.Currency
+		}
+		database.DB.Model(&evaluation).Updates(map[string]any{
+			"completed_rows": completed,
+			"failed_rows":    failed,
+			"total_tokens":   totalTokens,
+		})
+	}
+
+	updates := map[string]any{
+		"status":         evaluationStatusCompleted,
+		"completed_rows": completed,
+		"failed_rows":    failed,
+		"total_tokens":   totalTokens,
+		"cost":           cost,
+		"currency":       currency,
+		"finished_at":    time.Now(),
+	}
+	switch {
+	case ctx.Err() != nil:
+		updates["status"] = evaluationStatusCanceled
+	case completed > 0 && failed == completed:
+		// 所有行都失败时通常是鉴权或配置问题，标记为失败并记录第一条错误
+		updates["status"] = evaluationStatusFailed
+		updates["error"] = firstError
+	}
+	if err := database.DB.Model(&evaluation).Updates(updates).Error; err != nil {
+		slog.Error("Failed to update evaluation", "evaluation_id", evaluation.ID, "error", err)
+	}
+}
+
+// evaluateRow 渲染提示词并调用模型；行中有 Input 时提示词作为系统消息、Input 作为用户消息，
+// 否则提示词作为用户消息发送。任务被取消时返回 nil，不记录该行
+func (h *EvaluationHandler) evaluateRow(ctx context.Context, evaluationID string, row models.DatasetRow, call *evaluationCall) *models.EvaluationResult {
+	result := &models.EvaluationResult{
+		EvaluationID:   evaluationID,
+		RowID:          row.ID,
+		Position:       row.Position,
+		ExpectedOutput: row.ExpectedOutput,
+		CreatedAt:      time.Now(),
+	}
+
+	content, err := h.templateService.Render(call.prompt.Content, call.prompt.Variables, row.Variables)
+	if err != nil {
+		result.Status = resultStatusError
+		result.Error = err.Error()
+		result.ErrorType = "render"
+		return result
+	}
+
+	messages := []services.OpenAIMessage{{Role: "user", Content: content}}
+	if row.Input != "" {
+		messages = []services.OpenAIMessage{
+			{Role: "system", Content: content},
+			{Role: "user", Content: row.Input},
+		}
+	}
+	result.Messages = make(models.ChatMessages, len(messages))
+	for i, message := range messages {
+		result.Messages[i] = models.ChatMessage{Role: message.Role, Content: message.Content}
+	}
+
+	start := time.Now()
+	chat, err := services.CallModel(ctx, call.creds.Provider, call.creds.APIKey, call.creds.APIURL, call.options, messages)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		result.Status = resultStatusError
+		result.Error = err.Error()
+		_, result.ErrorType = providerErrorStatus(err)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		return result
+	}
+
+	result.Status = resultStatusSuccess
+	result.Output = chat.Content
+	result.FinishReason = chat.FinishReason
+	result.PromptTokens = chat.PromptTokens
+	result.CompletionTokens = chat.CompletionTokens
+	result.TotalTokens = chat.TotalTokens
+	result.LatencyMs = chat.LatencyMs
+	result.Cost = chat.Cost
+	result.Currency = chat.Currency
+	return result
+}
+
+// loadEvaluation 按 ID 加载评测任务，失败时直接写入错误响应
+func loadEvaluation(c *gin.Context, id string) (*models.Evaluation, bool) {
+	var evaluation models.Evaluation
+	if err := database.DB.First(&evaluation, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Evaluation not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch evaluation"})
+		return nil, false
+	}
+	return &evaluation, true
+}
+
+// FailInterruptedEvaluations 服务重启后，将上次未运行完的评测任务标记为失败
+func FailInterruptedEvaluations() error {
+	return database.DB.Model(&models.Evaluation{}).
+		Where("status IN ?", []string{evaluationStatusPending, evaluationStatusRunning}).
+		Updates(map[string]any{
+			"status":      evaluationStatusFailed,
+			"error":       "interrupted by server restart",
+			"finished_at": time.Now(),
+		}).Error
+}
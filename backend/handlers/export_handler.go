@@ -9,17 +9,23 @@ import (
 	"net/http"
 	"prompt-manager/database"
 	"prompt-manager/models"
+	"prompt-manager/services"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"sort"
 )
 
-type ExportHandler struct{}
+type ExportHandler struct {
+	versionService *services.VersionService
+}
 
 func NewExportHandler() *ExportHandler {
-	return &ExportHandler{}
+	return &ExportHandler{
+		versionService: services.NewVersionService(),
+	}
 }
 
 // ExportData 导出数据
@@ -161,20 +167,31 @@ func (h *ExportHandler) importJSON(c *gin.Context, file multipart.File) {
 	skippedCount := 0
 	errors := []string{}
 
+	var checks []string
 	for _, project := range data.Projects {
 		tx := database.DB.Begin()
 
-		// 检查项目是否存在
+		// 检查项目是否存在，回收站中的项目先恢复再导入
 		var existingProject models.Project
-		if err := tx.Where("id = ?", project.ID).First(&existingProject).Error; err != nil {
-			// 不存在，创建
-			if err := tx.Create(&project).Error; err != nil {
+		if err := tx.Unscoped().Where("id = ?", project.ID).First(&existingProject).Error; err != nil {
+			// 不存在，创建（提示词版本在下面逐条导入，提示词实体在导入后按名称重建）
+			project.PromptEntities = nil
+			if err := tx.Omit("Prompts", "PromptEntities").Create(&project).Error; err != nil {
 				tx.Rollback()
 				errors = append(errors, fmt.Sprintf("Failed to create project %s: %v", project.Name, err))
 				skippedCount++
 				continue
 			}
+			existingProject = project
 		} else {
+			if existingProject.DeletedAt.Valid {
+				if err := restoreProject(tx, &existingProject); err != nil {
+					tx.Rollback()
+					errors = append(errors, fmt.Sprintf("Failed to restore project %s: %v", project.Name, err))
+					skippedCount++
+					continue
+				}
+			}
 			// 存在，更新基本信息
 			existingProject.Name = project.Name
 			existingProject.Description = project.Description
@@ -184,30 +201,46 @@ func (h *ExportHandler) importJSON(c *gin.Context, file multipart.File) {
 				skippedCount++
 				continue
 			}
-			// 处理提示词
-			for _, prompt := range project.Prompts {
-				prompt.ProjectID = existingProject.ID
-				var existingPrompt models.Prompt
-				if err := tx.Where("id = ?", prompt.ID).First(&existingPrompt).Error; err != nil {
-					if err := tx.Create(&prompt).Error; err != nil {
-						// log error but maybe continue?
-					}
-				} else {
-					// Update prompt?
-					existingPrompt.Name = prompt.Name
-					existingPrompt.Version = prompt.Version
-					existingPrompt.Content = prompt.Content
-					existingPrompt.Description = prompt.Description
-					existingPrompt.Category = prompt.Category
-					tx.Save(&existingPrompt)
-				}
+		}
+
+		// 处理提示词：已存在的版本保持不变，不会被文件中的内容覆盖
+		var importedIDs []string
+		var importErr error
+		for _, prompt := range project.Prompts {
+			prompt.ProjectID = existingProject.ID
+			var count int64
+			if importErr = tx.Unscoped().Model(&models.Prompt{}).Where("id = ?", prompt.ID).Count(&count).Error; importErr != nil {
+				break
+			}
+			if count > 0 {
+				continue
+			}
+			if importErr = createImportedPrompt(tx, &prompt, requestActor(c, "")); importErr != nil {
+				importErr = fmt.Errorf("prompt %s %s: %v", prompt.Name, prompt.Version, importErr)
+				break
 			}
+			importedIDs = append(importedIDs, prompt.ID)
+		}
+		var pending []string
+		if importErr == nil {
+			pending, importErr = linkImportedPrompts(tx, importedIDs)
+		}
+		if importErr != nil {
+			tx.Rollback()
+			errors = append(errors, fmt.Sprintf("Failed to import prompts of project %s: %v", project.Name, importErr))
+			skippedCount++
+			continue
 		}
 
 		tx.Commit()
+		checks = append(checks, pending...)
 		importedCount++
 	}
 
+	for _, id := range checks {
+		startVersionCheck(id)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":  true,
 		"message":  "Import completed",
@@ -285,7 +318,7 @@ func (h *ExportHandler) importCSV(c *gin.Context, file multipart.File) {
 		// If prompt data exists
 		if promptID != "" {
 			var prompt models.Prompt
-			if err := database.DB.Where("id = ?", promptID).First(&prompt).Error; err != nil {
+			if err := database.DB.Unscoped().Where("id = ?", promptID).First(&prompt).Error; err != nil {
 				// Create
 				createdAt, _ := time.Parse("2006-01-02 15:04:05", createdAtStr)
 				if createdAt.IsZero() {
@@ -317,16 +350,29 @@ func (h *ExportHandler) importCSV(c *gin.Context, file multipart.File) {
 						if name == "" { continue }
 						var tag models.Tag
 						if err := database.DB.Where("name = ?", name).First(&tag).Error; err != nil {
-							tag = models.Tag{Name: name}
-							database.DB.Create(&tag)
+							if restored, _ := restoreTrashedByName(&tag, name, ""); !restored {
+								tag = models.Tag{Name: name}
+								database.DB.Create(&tag)
+							}
 						}
 						prompt.Tags = append(prompt.Tags, tag)
 					}
 				}
 				
-				if err := database.DB.Create(&prompt).Error; err != nil {
+				tx := database.DB.Begin()
+				err := createImportedPrompt(tx, &prompt, requestActor(c, ""))
+				var pending []string
+				if err == nil {
+					pending, err = linkImportedPrompts(tx, []string{prompt.ID})
+				}
+				if err != nil {
+					tx.Rollback()
 					errors = append(errors, fmt.Sprintf("Failed to create prompt %s: %v", promptID, err))
 				} else {
+					tx.Commit()
+					for _, id := range pending {
+						startVersionCheck(id)
+					}
 					importedCount++
 				}
 			} else {
@@ -346,6 +392,66 @@ func (h *ExportHandler) importCSV(c *gin.Context, file multipart.File) {
 	})
 }
 
+// createImportedPrompt 创建导入的版本。与新建版本一样从初始状态开始：忽略文件中的回归检查和审批状态，
+// 需要审批的项目中为草稿；回归检查状态在关联提示词实体后由 linkImportedPrompts 确定
+func createImportedPrompt(tx *gorm.DB, prompt *models.Prompt, actor string) error {
+	prompt.EntityID = ""
+	prompt.CheckStatus = ""
+	prompt.CheckAccepted = false
+	prompt.DeletedAt = gorm.DeletedAt{}
+	switch prompt.Status {
+	case promptStatusDraft, promptStatusPublished, promptStatusDeprecated, promptStatusArchived:
+	default:
+		prompt.Status = promptStatusPublished
+	}
+	reviewStatus, err := initialReviewStatus(tx, prompt.ProjectID, false)
+	if err != nil {
+		return err
+	}
+	prompt.ReviewStatus = reviewStatus
+
+	if err := tx.Create(prompt).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.PromptHistory{
+		PromptID:   prompt.ID,
+		Operation:  "create",
+		Author:     actor,
+		NewContent: prompt.Content,
+		CreatedAt:  time.Now(),
+	}).Error
+}
+
+// linkImportedPrompts 为导入的版本关联提示词实体，已有测试用例的提示词将新版本标记为待检查，
+// 返回需要在事务提交后执行回归检查的版本
+func linkImportedPrompts(tx *gorm.DB, promptIDs []string) ([]string, error) {
+	if len(promptIDs) == 0 {
+		return nil, nil
+	}
+	if err := database.BackfillPromptEntities(tx); err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, id := range promptIDs {
+		var prompt models.Prompt
+		if err := tx.Select("id", "entity_id").First(&prompt, "id = ?", id).Error; err != nil {
+			return nil, err
+		}
+		status, err := pendingCheckStatus(tx, prompt.EntityID)
+		if err != nil {
+			return nil, err
+		}
+		if status == "" {
+			continue
+		}
+		if err := tx.Model(&models.Prompt{}).Where("id = ?", id).UpdateColumn("check_status", status).Error; err != nil {
+			return nil, err
+		}
+		pending = append(pending, id)
+	}
+	return pending, nil
+}
+
 func (h *ExportHandler) exportYAML(c *gin.Context, projects []models.Project) {
 	filename := fmt.Sprintf("prompts_export_%s.yaml", time.Now().Format("20060102_150405"))
 
@@ -355,15 +461,18 @@ func (h *ExportHandler) exportYAML(c *gin.Context, projects []models.Project) {
 	exportData := make(map[string]string)
 
 	for _, project := range projects {
-		latestPrompts := make(map[string]models.Prompt)
+		// 每个名称只导出语义化版本最新的一条
+		promptsByName := make(map[string][]models.Prompt)
 		for _, prompt := range project.Prompts {
-			if existing, ok := latestPrompts[prompt.Name]; !ok || prompt.Version > existing.Version {
-				latestPrompts[prompt.Name] = prompt
-			}
+			promptsByName[prompt.Name] = append(promptsByName[prompt.Name], prompt)
 		}
 
-		for _, prompt := range latestPrompts {
-			exportData[prompt.Name] = prompt.Content
+		for name, prompts := range promptsByName {
+			versions := make([]string, len(prompts))
+			for i, prompt := range prompts {
+				versions[i] = prompt.Version
+			}
+			exportData[name] = prompts[h.versionService.Latest(versions)].Content
 		}
 	}
 
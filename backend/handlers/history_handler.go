@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HistoryHandler struct {
+	versionService  *services.VersionService
+	diffService     *services.DiffService
+	templateService *services.TemplateService
+}
+
+func NewHistoryHandler() *HistoryHandler {
+	return &HistoryHandler{
+		versionService:  services.NewVersionService(),
+		diffService:     services.NewDiffService(),
+		templateService: services.NewTemplateService(),
+	}
+}
+
+// historyEntry 历史记录及其内容差异
+type historyEntry struct {
+	models.PromptHistory
+	Diff services.DiffResult `json:"diff"`
+}
+
+// GetPromptHistory 获取提示词（同项目同名称的所有版本）的操作历史
+func (h *HistoryHandler) GetPromptHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	var prompt models.Prompt
+	if err := database.DB.First(&prompt, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+		return
+	}
+
+	query := h.historyQuery(c).
+		Where("prompts.project_id = ? AND prompts.name = ?", prompt.ProjectID, prompt.Name)
+	h.writeHistoryPage(c, query)
+}
+
+// GetProjectHistory 获取项目下所有提示词的操作时间线
+func (h *HistoryHandler) GetProjectHistory(c *gin.Context) {
+	query := h.historyQuery(c).Where("prompts.project_id = ?", c.Param("id"))
+	if name := c.Query("name"); name != "" {
+		query = query.Where("prompts.name = ?", name)
+	}
+	h.writeHistoryPage(c, query)
+}
+
+// RestoreHistory 将某条历史记录的内容恢复为一个新版本
+// 默认恢复该次操作后的内容，side=old 时恢复操作前的内容
+func (h *HistoryHandler) RestoreHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	var history models.PromptHistory
+	if err := database.DB.First(&history, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "History not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+
+	content := history.NewContent
+	if c.Query("side") == "old" {
+		content = history.OldContent
+	}
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "history entry has no content to restore"})
+		return
+	}
+
+	var source models.Prompt
+	if err := database.DB.First(&source, "id = ?", history.PromptID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+		return
+	}
+
+	newPrompt, ok := createDerivedVersion(c, h.versionService, &source, content,
+		fmt.Sprintf("Restore from history of version %s", source.Version),
+		h.templateService.DetectSchema(content, source.Variables), "restore")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newPrompt)
+}
+
+// historyQuery 构建历史记录查询，支持按操作类型和时间范围筛选
+func (h *HistoryHandler) historyQuery(c *gin.Context) *gorm.DB {
+	query := database.DB.Model(&models.PromptHistory{}).
+		Joins("JOIN prompts ON prompts.id = prompt_histories.prompt_id AND prompts.deleted_at IS NULL")
+
+	if operation := c.Query("operation"); operation != "" {
+		query = query.Where("prompt_histories.operation = ?", operation)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("prompt_histories.created_at >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("prompt_histories.created_at <= ?", endDate)
+	}
+	return query
+}
+
+// writeHistoryPage 分页输出历史记录，并附带每条记录的内容差异
+func (h *HistoryHandler) writeHistoryPage(c *gin.Context, query *gorm.DB) {
+	page, pageSize, offset := parsePagination(c)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count history"})
+		return
+	}
+
+	var histories []models.PromptHistory
+	if err := query.Preload("Prompt", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "project_id", "name", "version", "created_at")
+	}).Order("prompt_histories.created_at DESC").Offset(offset).Limit(pageSize).Find(&histories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+
+	entries := make([]historyEntry, len(histories))
+	for i, history := range histories {
+		entries[i] = historyEntry{
+			PromptHistory: history,
+			Diff:          h.diffService.CompareTexts(history.OldContent, history.NewContent),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      entries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
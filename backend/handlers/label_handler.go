@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// labelPattern 标签名只允许小写字母、数字、中划线和下划线
+var labelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+type LabelHandler struct{}
+
+func NewLabelHandler() *LabelHandler {
+	return &LabelHandler{}
+}
+
+// GetLabels 获取项目下的发布标签
+func (h *LabelHandler) GetLabels(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var labels []models.PromptLabel
+	query := database.DB.Where("project_id = ?", projectID)
+	if name := c.Query("name"); name != "" {
+		query = query.Where("prompt_name = ?", name)
+	}
+	if err := query.Order("prompt_name ASC, label ASC").Find(&labels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch labels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  labels,
+		"total": len(labels),
+	})
+}
+
+// PromoteLabel 将发布标签指向指定版本（不存在则创建）
+func (h *LabelHandler) PromoteLabel(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var req struct {
+		Name     string `json:"name" binding:"required"`
+		Label    string `json:"label" binding:"required"`
+		PromptID string `json:"prompt_id"`
+		Version  string `json:"version"`
+		Operator string `json:"operator"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !labelPattern.MatchString(req.Label) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label, use lowercase letters, digits, '-' or '_'"})
+		return
+	}
+	if req.PromptID == "" && req.Version == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt_id or version is required"})
+		return
+	}
+
+	// 查找目标版本，必须属于同一项目同一名称
+	query := database.DB.Where("project_id = ? AND name = ?", projectID, req.Name)
+	if req.PromptID != "" {
+		query = query.Where("id = ?", req.PromptID)
+	}
+	pick := func(versions []string) int { return len(versions) - 1 }
+	if req.Version != "" {
+		query = query.Where("version IN ?", versionLookup(req.Version))
+		pick = pickExactVersion(req.Version)
+	}
+	target, err := findPromptVersion(query, pick)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt version not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+		return
+	}
+
+	if target.ReviewStatus != "" && target.ReviewStatus != reviewStatusApproved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt version has not been approved"})
+		return
+	}
+	if target.Status == promptStatusDraft || target.Status == promptStatusArchived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt version is " + target.Status + ", publish it before promoting"})
+		return
+	}
+
+	actor := requestActor(c, req.Operator)
+	now := time.Now()
+
+	tx := database.DB.Begin()
+	var label models.PromptLabel
+	history := models.PromptLabelHistory{
+		ProjectID:  projectID,
+		PromptName: req.Name,
+		Label:      req.Label,
+		Operation:  "promote",
+		ToPromptID: target.ID,
+		ToVersion:  target.Version,
+		Actor:      actor,
+		CreatedAt:  now,
+	}
+
+	err = tx.Where("project_id = ? AND prompt_name = ? AND label = ?", projectID, req.Name, req.Label).First(&label).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		label = models.PromptLabel{
+			ProjectID:  projectID,
+			PromptName: req.Name,
+			Label:      req.Label,
+			PromptID:   target.ID,
+			Version:    target.Version,
+			UpdatedBy:  actor,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := tx.Create(&label).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label"})
+			return
+		}
+	case err != nil:
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch label"})
+		return
+	default:
+		if label.PromptID == target.ID {
+			tx.Rollback()
+			c.JSON(http.StatusOK, label)
+			return
+		}
+		history.FromPromptID = label.PromptID
+		history.FromVersion = label.Version
+		label.PromptID = target.ID
+		label.Version = target.Version
+		label.UpdatedBy = actor
+		label.UpdatedAt = now
+		if err := tx.Save(&label).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update label"})
+			return
+		}
+	}
+
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label history"})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, label)
+}
+
+// DemoteLabel 移除发布标签
+func (h *LabelHandler) DemoteLabel(c *gin.Context) {
+	projectID := c.Param("id")
+	labelName := c.Param("label")
+	name := c.Query("name")
+
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt name is required"})
+		return
+	}
+
+	var label models.PromptLabel
+	if err := database.DB.Where("project_id = ? AND prompt_name = ? AND label = ?", projectID, name, labelName).First(&label).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch label"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Delete(&label).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	history := models.PromptLabelHistory{
+		ProjectID:    projectID,
+		PromptName:   name,
+		Label:        labelName,
+		Operation:    "demote",
+		FromPromptID: label.PromptID,
+		FromVersion:  label.Version,
+		Actor:        requestActor(c, c.Query("operator")),
+		CreatedAt:    time.Now(),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label history"})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Label removed successfully"})
+}
+
+// GetLabelHistory 获取发布标签的移动记录
+func (h *LabelHandler) GetLabelHistory(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var histories []models.PromptLabelHistory
+	query := database.DB.Where("project_id = ?", projectID)
+	if name := c.Query("name"); name != "" {
+		query = query.Where("prompt_name = ?", name)
+	}
+	if label := c.Query("label"); label != "" {
+		query = query.Where("label = ?", label)
+	}
+	if err := query.Order("created_at DESC").Find(&histories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch label history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  histories,
+		"total": len(histories),
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// listSpec 列表接口可排序、可选择的字段
+type listSpec struct {
+	table       string
+	sortable    []string          // 可排序的字段，字段名与列名相同
+	defaultSort string            // 默认排序，"-" 前缀表示倒序
+	fields      map[string]string // 可选择的 JSON 字段 → 列名，列名为空表示预加载的关联数据
+}
+
+// listCursor 游标分页的位置：上一页最后一条记录的排序字段值和 ID
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// listQuery 列表请求的分页、排序和字段选择参数
+// 未传 page、page_size、cursor 时不分页，返回全部记录
+type listQuery struct {
+	spec     *listSpec
+	sort     string
+	desc     bool
+	paged    bool
+	cursor   *listCursor // 使用游标分页时不为空，第一页为零值
+	page     int
+	pageSize int
+	offset   int
+	fields   map[string]bool // 为空表示返回全部字段
+}
+
+// parseListQuery 解析 page、page_size、cursor、sort、fields 参数，失败时直接写入错误响应
+func parseListQuery(c *gin.Context, spec *listSpec) (*listQuery, bool) {
+	q := &listQuery{spec: spec}
+
+	sortParam := c.DefaultQuery("sort", spec.defaultSort)
+	q.sort = strings.TrimPrefix(sortParam, "-")
+	q.desc = strings.HasPrefix(sortParam, "-")
+	valid := false
+	for _, field := range spec.sortable {
+		valid = valid || field == q.sort
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, expected one of " + strings.Join(spec.sortable, ", ") + " (prefix with - for descending)"})
+		return nil, false
+	}
+
+	if fields := c.Query("fields"); fields != "" {
+		q.fields = map[string]bool{"id": true}
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if _, ok := spec.fields[field]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid field: " + field})
+				return nil, false
+			}
+			q.fields[field] = true
+		}
+	}
+
+	_, hasPage := c.GetQuery("page")
+	_, hasPageSize := c.GetQuery("page_size")
+	cursor, hasCursor := c.GetQuery("cursor")
+	if !hasPage && !hasPageSize && !hasCursor {
+		return q, true
+	}
+	q.paged = true
+	q.page, q.pageSize, q.offset = parsePagination(c)
+	if hasCursor {
+		q.cursor = &listCursor{}
+		if cursor != "" {
+			data, err := base64.RawURLEncoding.DecodeString(cursor)
+			if err != nil || json.Unmarshal(data, q.cursor) != nil || q.cursor.Sort != sortParam {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return nil, false
+			}
+		}
+	}
+	return q, true
+}
+
+// wants 是否需要返回某个字段，用于决定是否预加载关联数据
+func (q *listQuery) wants(field string) bool {
+	return q.fields == nil || q.fields[field]
+}
+
+// column 返回带表名的列名，避免联表查询时列名冲突
+func (q *listQuery) column(name string) string {
+	return q.spec.table + "." + name
+}
+
+// selectColumns 只查询 fields 中的列，另外始终查询 ID 和排序字段以生成游标
+func (q *listQuery) selectColumns(query *gorm.DB) *gorm.DB {
+	if q.fields == nil {
+		return query
+	}
+	columns := []string{q.column("id"), q.column(q.sort)}
+	for field := range q.fields {
+		if column := q.spec.fields[field]; column != "" && column != "id" && column != q.sort {
+			columns = append(columns, q.column(column))
+		}
+	}
+	sort.Strings(columns[2:])
+	return query.Select(columns)
+}
+
+// apply 在查询上应用字段选择、排序、游标条件和分页
+func (q *listQuery) apply(query *gorm.DB) *gorm.DB {
+	query = q.selectColumns(query)
+
+	direction, compare := "ASC", ">"
+	if q.desc {
+		direction, compare = "DESC", "<"
+	}
+	query = query.Order(q.column(q.sort) + " " + direction).Order(q.column("id") + " " + direction)
+	if !q.paged {
+		return query
+	}
+
+	if q.cursor != nil {
+		if q.cursor.ID != "" {
+			value := q.cursorValue()
+			query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))",
+				q.column(q.sort), compare, q.column(q.sort), q.column("id"), compare), value, value, q.cursor.ID)
+		}
+		return query.Limit(q.pageSize)
+	}
+	return query.Offset(q.offset).Limit(q.pageSize)
+}
+
+// cursorValue 时间字段的游标值需还原为时间，才能与数据库中的值正确比较
+func (q *listQuery) cursorValue() any {
+	if strings.HasSuffix(q.sort, "_at") {
+		if t, err := time.Parse(time.RFC3339Nano, q.cursor.Value); err == nil {
+			return t
+		}
+	}
+	return q.cursor.Value
+}
+
+// respond 输出列表响应：按 fields 裁剪字段，分页时附带页码信息，还有下一页时附带 next_cursor
+func (q *listQuery) respond(c *gin.Context, rows any, total int64) {
+	items, err := listItems(rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode results"})
+		return
+	}
+
+	var nextCursor string
+	if q.paged && len(items) == q.pageSize {
+		last := items[len(items)-1]
+		sortParam := q.sort
+		if q.desc {
+			sortParam = "-" + sortParam
+		}
+		nextCursor = encodeListCursor(listCursor{Sort: sortParam, Value: fmt.Sprint(last[q.sort]), ID: fmt.Sprint(last["id"])})
+	}
+
+	if q.fields != nil {
+		for _, item := range items {
+			for key := range item {
+				if !q.fields[key] {
+					delete(item, key)
+				}
+			}
+		}
+	}
+
+	resp := gin.H{"data": items, "total": total}
+	if q.paged {
+		resp["page_size"] = q.pageSize
+		if q.cursor == nil {
+			resp["page"] = q.page
+		}
+		if nextCursor != "" {
+			resp["next_cursor"] = nextCursor
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// listItems 将记录转换为 JSON 对象列表，便于裁剪字段
+func listItems(rows any) ([]map[string]any, error) {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+	items := []map[string]any{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
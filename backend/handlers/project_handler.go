@@ -21,28 +21,59 @@ func NewProjectHandler() *ProjectHandler {
 	}
 }
 
-// GetProjects 获取项目列表
+// projectListSpec 项目列表可排序、可选择的字段
+var projectListSpec = &listSpec{
+	table:       "projects",
+	sortable:    []string{"name", "created_at", "updated_at"},
+	defaultSort: "created_at",
+	fields: map[string]string{
+		"id": "id", "name": "name", "description": "description", "created_at": "created_at",
+		"updated_at": "updated_at", "required_approvals": "required_approvals",
+		"tags": "", "prompts": "", "prompt_entities": "",
+	},
+}
+
+// GetProjects 获取项目列表，支持分页、排序和字段选择
 func (h *ProjectHandler) GetProjects(c *gin.Context) {
-	var projects []models.Project
+	q, ok := parseListQuery(c, projectListSpec)
+	if !ok {
+		return
+	}
 	
-	query := database.DB.Preload("Tags").Preload("Prompts", func(db *gorm.DB) *gorm.DB {
-		return db.Select("id", "project_id", "created_at", "name") // 只查必要字段
-	})
+	query := database.DB.Model(&models.Project{})
 	
 	// 搜索过滤
 	if search := c.Query("search"); search != "" {
-		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Where("(projects.name LIKE ? OR projects.description LIKE ?)", "%"+search+"%", "%"+search+"%")
+	}
+	
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count projects"})
+		return
 	}
 	
-	if err := query.Find(&projects).Error; err != nil {
+	if q.wants("tags") {
+		query = query.Preload("Tags")
+	}
+	if q.wants("prompts") {
+		query = query.Preload("Prompts", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "project_id", "created_at", "name") // 只查必要字段
+		})
+	}
+	if q.wants("prompt_entities") {
+		query = query.Preload("PromptEntities", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "project_id", "name")
+		})
+	}
+	
+	var projects []models.Project
+	if err := q.apply(query).Find(&projects).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
-		"data": projects,
-		"total": len(projects),
-	})
+	q.respond(c, projects, total)
 }
 
 // GetProject 获取单个项目详情
@@ -51,7 +82,9 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 	
 	var project models.Project
 	if err := database.DB.Preload("Tags").Preload("Prompts", func(db *gorm.DB) *gorm.DB {
-		return db.Select("id", "project_id", "created_at", "name").Order("created_at DESC")
+		return db.Select("id", "project_id", "entity_id", "created_at", "name").Order("created_at DESC")
+	}).Preload("PromptEntities", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "project_id", "name", "category", "updated_at").Order("name ASC")
 	}).First(&project, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
@@ -131,48 +164,98 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	c.JSON(http.StatusOK, project)
 }
 
-// DeleteProject 删除项目
+// DeleteProject 将项目移入回收站，项目下的提示词版本随项目一起移入回收站
 func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	id := c.Param("id")
 	
-	tx := database.DB.Begin()
-	
-	// 1. 获取该项目下的所有 Prompt IDs
-	var promptIDs []string
-	if err := tx.Model(&models.Prompt{}).Where("project_id = ?", id).Pluck("id", &promptIDs).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project prompts"})
-		return
-	}
-	
-	// 2. 删除 prompt_tags 中关联的记录
-	if len(promptIDs) > 0 {
-		if err := tx.Exec("DELETE FROM prompt_tags WHERE prompt_id IN ?", promptIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prompt tags"})
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 			return
 		}
-		// 3. 删除 prompt_history 记录 (虽然设置了级联，但手动清理更保险)
-		// 注意：表名可能是 prompt_histories
-		if err := tx.Exec("DELETE FROM prompt_histories WHERE prompt_id IN ?", promptIDs).Error; err != nil {
-			// 忽略错误，因为可能是表名不同或已被级联删除
-		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
 	}
 	
-	// 4. 删除 project_tags 关联 (如果存在)
-	if err := tx.Exec("DELETE FROM project_tags WHERE project_id = ?", id).Error; err != nil {
+	// 项目与提示词使用同一删除时间，恢复项目时据此恢复随项目删除的版本
+	now := time.Now()
+	tx := database.DB.Begin()
+	if err := tx.Model(&models.Prompt{}).Where("project_id = ?", id).Update("deleted_at", now).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project tags"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project prompts"})
 		return
 	}
-	
-	// 5. 删除项目 (级联删除 Prompts)
-	if err := tx.Delete(&models.Project{}, "id = ?", id).Error; err != nil {
+	if err := tx.Model(&project).Update("deleted_at", now).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
 		return
 	}
-	
 	tx.Commit()
+	
 	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
-}
\ No newline at end of file
+}
+
+// purgeProject 彻底删除项目及其提示词版本、标签关联、历史记录、实体、数据集等全部数据
+func purgeProject(tx *gorm.DB, id string) error {
+	// 1. 获取该项目下的所有 Prompt IDs（含回收站中的版本）
+	var promptIDs []string
+	if err := tx.Unscoped().Model(&models.Prompt{}).Where("project_id = ?", id).Pluck("id", &promptIDs).Error; err != nil {
+		return err
+	}
+	
+	// 2. 删除提示词版本及其标签关联、历史记录、检查结果和审批记录
+	if err := purgePrompts(tx, promptIDs); err != nil {
+		return err
+	}
+	
+	// 3. 删除 project_tags 关联
+	if err := deleteJoinRows(tx, "project_tags", "project_id", id); err != nil {
+		return err
+	}
+	
+	// 4. 删除发布标签及其移动记录
+	if err := tx.Where("project_id = ?", id).Delete(&models.PromptLabel{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("project_id = ?", id).Delete(&models.PromptLabelHistory{}).Error; err != nil {
+		return err
+	}
+	
+	// 5. 删除 API Key 的项目授权
+	if err := deleteJoinRows(tx, "api_key_projects", "project_id", id); err != nil {
+		return err
+	}
+	
+	// 6. 删除项目成员角色
+	if err := tx.Where("project_id = ?", id).Delete(&models.ProjectMember{}).Error; err != nil {
+		return err
+	}
+	
+	// 7. 删除提示词实体及其标签关联、测试用例
+	var entityIDs []string
+	if err := tx.Model(&models.PromptEntity{}).Where("project_id = ?", id).Pluck("id", &entityIDs).Error; err != nil {
+		return err
+	}
+	if err := deleteJoinRows(tx, "prompt_entity_tags", "prompt_entity_id", entityIDs); err != nil {
+		return err
+	}
+	if err := tx.Where("entity_id IN ?", entityIDs).Delete(&models.PromptTestCase{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("project_id = ?", id).Delete(&models.PromptEntity{}).Error; err != nil {
+		return err
+	}
+	
+	// 8. 删除评测数据集、评测任务及结果
+	var datasetIDs []string
+	if err := tx.Model(&models.Dataset{}).Where("project_id = ?", id).Pluck("id", &datasetIDs).Error; err != nil {
+		return err
+	}
+	if err := deleteDatasets(tx, datasetIDs); err != nil {
+		return err
+	}
+
+	// 9. 删除项目
+	return tx.Unscoped().Delete(&models.Project{}, "id = ?", id).Error
+}
@@ -0,0 +1,484 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 提示词版本的回归检查状态，空字符串表示该提示词没有测试用例
+const (
+	checkStatusPending = "pending"
+	checkStatusPassed  = "passed"
+	checkStatusFailed  = "failed"
+)
+
+// 单个测试用例的检查结果
+const (
+	caseStatusPassed = "passed"
+	caseStatusFailed = "failed"
+	caseStatusError  = "error"
+)
+
+const (
+	// maxPromptTestCases 单个提示词最多保存的测试用例数
+	maxPromptTestCases = 50
+	// checkConcurrency 回归检查同时执行的测试用例数
+	checkConcurrency = 4
+)
+
+// servableCheckStatuses SDK 未指定具体版本时可以提供的检查状态，未通过的版本需强制接受后才可提供
+var servableCheckStatuses = []string{"", checkStatusPassed}
+
+// versionCheck 运行中的回归检查，同一版本重新检查时先取消并等待上一次检查结束
+type versionCheck struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// runningChecks 运行中的回归检查，按提示词版本 ID 索引
+var (
+	runningChecks   = map[string]*versionCheck{}
+	runningChecksMu sync.Mutex
+)
+
+// checkRunner 执行回归检查使用的服务
+var checkRunner = struct {
+	templateService  *services.TemplateService
+	assertionService *services.AssertionService
+}{
+	templateService:  services.NewTemplateService(),
+	assertionService: services.NewAssertionService(),
+}
+
+type PromptCheckHandler struct {
+	assertionService *services.AssertionService
+}
+
+func NewPromptCheckHandler() *PromptCheckHandler {
+	return &PromptCheckHandler{
+		assertionService: services.NewAssertionService(),
+	}
+}
+
+// testCaseRequest 创建或更新测试用例的请求
+type testCaseRequest struct {
+	Name       string                `json:"name"`
+	Variables  models.VariableValues `json:"variables"`
+	Messages   models.ChatMessages   `json:"messages"`
+	Assertions models.Assertions     `json:"assertions"`
+	Provider   string                `json:"provider"`
+	Model      string                `json:"model"`
+}
+
+// GetTestCases 获取提示词的回归测试用例
+func (h *PromptCheckHandler) GetTestCases(c *gin.Context) {
+	entity, ok := loadPromptEntity(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var testCases []models.PromptTestCase
+	if err := database.DB.Where("entity_id = ?", entity.ID).Order("created_at ASC").Find(&testCases).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test cases"})
+		return
+	}
+	c.JSON(http.StatusOK, testCases)
+}
+
+// CreateTestCase 为提示词添加回归测试用例，之后创建的新版本都会执行
+func (h *PromptCheckHandler) CreateTestCase(c *gin.Context) {
+	entity, ok := loadPromptEntity(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req testCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if err := h.assertionService.Validate(req.Assertions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.PromptTestCase{}).Where("entity_id = ?", entity.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count test cases"})
+		return
+	}
+	if count >= maxPromptTestCases {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many test cases for this prompt"})
+		return
+	}
+
+	testCase := models.PromptTestCase{
+		EntityID:   entity.ID,
+		Name:       req.Name,
+		Variables:  req.Variables,
+		Messages:   req.Messages,
+		Assertions: req.Assertions,
+		Provider:   req.Provider,
+		Model:      req.Model,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := database.DB.Create(&testCase).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create test case"})
+		return
+	}
+	c.JSON(http.StatusCreated, testCase)
+}
+
+// UpdateTestCase 更新测试用例，只修改请求中提供的字段
+func (h *PromptCheckHandler) UpdateTestCase(c *gin.Context) {
+	testCase, ok := loadTestCase(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req testCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.assertionService.Validate(req.Assertions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		testCase.Name = req.Name
+	}
+	if req.Variables != nil {
+		testCase.Variables = req.Variables
+	}
+	if req.Messages != nil {
+		testCase.Messages = req.Messages
+	}
+	if req.Assertions != nil {
+		testCase.Assertions = req.Assertions
+	}
+	if req.Provider != "" {
+		testCase.Provider = req.Provider
+	}
+	if req.Model != "" {
+		testCase.Model = req.Model
+	}
+	testCase.UpdatedAt = time.Now()
+
+	if err := database.DB.Save(testCase).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update test case"})
+		return
+	}
+	c.JSON(http.StatusOK, testCase)
+}
+
+// DeleteTestCase 删除测试用例，已有版本的检查结果保留
+func (h *PromptCheckHandler) DeleteTestCase(c *gin.Context) {
+	testCase, ok := loadTestCase(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(testCase).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete test case"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test case deleted successfully"})
+}
+
+// GetPromptCheck 获取提示词版本的回归检查状态和各测试用例的结果
+func (h *PromptCheckHandler) GetPromptCheck(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var results []models.PromptCheckResult
+	if err := database.DB.Where("prompt_id = ?", prompt.ID).Order("position ASC").Find(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch check results"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"prompt_id":      prompt.ID,
+		"version":        prompt.Version,
+		"check_status":   prompt.CheckStatus,
+		"check_accepted": prompt.CheckAccepted,
+		"results":        results,
+	})
+}
+
+// RunPromptCheck 重新执行提示词版本的回归检查，正在执行的检查会被取消
+func (h *PromptCheckHandler) RunPromptCheck(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	status, err := pendingCheckStatus(database.DB, prompt.EntityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test cases"})
+		return
+	}
+	if status == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt has no test cases"})
+		return
+	}
+
+	if err := database.DB.Model(prompt).Update("check_status", checkStatusPending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start check"})
+		return
+	}
+	startVersionCheck(prompt.ID)
+	c.JSON(http.StatusAccepted, gin.H{"prompt_id": prompt.ID, "version": prompt.Version, "check_status": checkStatusPending})
+}
+
+// AcceptPromptCheck 强制接受未通过（或尚未完成）回归检查的版本，使其可以由 SDK 提供
+func (h *PromptCheckHandler) AcceptPromptCheck(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if prompt.CheckStatus != checkStatusPending && prompt.CheckStatus != checkStatusFailed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt version does not need to be accepted"})
+		return
+	}
+
+	if err := database.DB.Model(prompt).Update("check_accepted", true).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept prompt version"})
+		return
+	}
+	c.JSON(http.StatusOK, prompt)
+}
+
+// pendingCheckStatus 新版本的初始检查状态：提示词有测试用例时为 pending，否则为空
+func pendingCheckStatus(tx *gorm.DB, entityID string) (string, error) {
+	if entityID == "" {
+		return "", nil
+	}
+	var count int64
+	if err := tx.Model(&models.PromptTestCase{}).Where("entity_id = ?", entityID).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+	return checkStatusPending, nil
+}
+
+// startVersionCheck 在后台执行提示词版本的回归检查
+func startVersionCheck(promptID string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	check := &versionCheck{cancel: cancel, done: make(chan struct{})}
+
+	runningChecksMu.Lock()
+	previous := runningChecks[promptID]
+	runningChecks[promptID] = check
+	runningChecksMu.Unlock()
+
+	go func() {
+		defer close(check.done)
+		defer func() {
+			runningChecksMu.Lock()
+			if runningChecks[promptID] == check {
+				delete(runningChecks, promptID)
+			}
+			runningChecksMu.Unlock()
+			cancel()
+		}()
+
+		if previous != nil {
+			previous.cancel()
+			<-previous.done
+		}
+		if err := runVersionCheck(ctx, promptID); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("Failed to check prompt version", "prompt_id", promptID, "error", err)
+		}
+	}()
+}
+
+// cancelVersionCheck 取消提示词版本正在执行的回归检查
+func cancelVersionCheck(promptID string) {
+	runningChecksMu.Lock()
+	defer runningChecksMu.Unlock()
+	if check, ok := runningChecks[promptID]; ok {
+		check.cancel()
+	}
+}
+
+// runVersionCheck 执行提示词版本的全部测试用例并记录结果，全部通过时状态为 passed，否则为 failed
+func runVersionCheck(ctx context.Context, promptID string) error {
+	var prompt models.Prompt
+	if err := database.DB.First(&prompt, "id = ?", promptID).Error; err != nil {
+		return err
+	}
+	var testCases []models.PromptTestCase
+	if err := database.DB.Where("entity_id = ?", prompt.EntityID).Order("created_at ASC").Find(&testCases).Error; err != nil {
+		return err
+	}
+
+	results := make([]*models.PromptCheckResult, len(testCases))
+	sem := make(chan struct{}, checkConcurrency)
+	var wg sync.WaitGroup
+	for i := range testCases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = runTestCase(ctx, &prompt, &testCases[i])
+			results[i].Position = i + 1
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	status := checkStatusPassed
+	for _, result := range results {
+		if result.Status != caseStatusPassed {
+			status = checkStatusFailed
+		}
+	}
+	if len(testCases) == 0 {
+		status = ""
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("prompt_id = ?", prompt.ID).Delete(&models.PromptCheckResult{}).Error; err != nil {
+			return err
+		}
+		for _, result := range results {
+			if err := tx.Create(result).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&prompt).Update("check_status", status).Error
+	})
+}
+
+// runTestCase 渲染提示词并调用模型执行单个测试用例，ctx 取消时结果不可用
+func runTestCase(ctx context.Context, prompt *models.Prompt, testCase *models.PromptTestCase) *models.PromptCheckResult {
+	result := &models.PromptCheckResult{
+		PromptID:   prompt.ID,
+		TestCaseID: testCase.ID,
+		Name:       testCase.Name,
+		Provider:   testCase.Provider,
+		Model:      testCase.Model,
+		CreatedAt:  time.Now(),
+	}
+
+	content, err := checkRunner.templateService.Render(prompt.Content, prompt.Variables, testCase.Variables)
+	if err != nil {
+		result.Status = caseStatusError
+		result.Error = err.Error()
+		result.ErrorType = "render"
+		return result
+	}
+
+	messages := []services.OpenAIMessage{{Role: "user", Content: content}}
+	if len(testCase.Messages) > 0 {
+		messages = []services.OpenAIMessage{{Role: "system", Content: content}}
+		for _, message := range testCase.Messages {
+			messages = append(messages, services.OpenAIMessage{Role: message.Role, Content: message.Content})
+		}
+	}
+
+	creds, err := lookupProviderCredentials(testCase.Provider, testCase.Model)
+	if err != nil {
+		result.Status = caseStatusError
+		result.Error = err.Error()
+		result.ErrorType = "config"
+		return result
+	}
+	result.Provider = string(creds.Provider)
+	result.Model = creds.Model
+
+	start := time.Now()
+	chat, err := services.CallModel(ctx, creds.Provider, creds.APIKey, creds.APIURL, services.ChatOptions{Model: creds.Model}, messages)
+	if err != nil {
+		result.Status = caseStatusError
+		result.Error = err.Error()
+		_, result.ErrorType = providerErrorStatus(err)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		return result
+	}
+	result.Output = chat.Content
+	result.PromptTokens = chat.PromptTokens
+	result.CompletionTokens = chat.CompletionTokens
+	result.TotalTokens = chat.TotalTokens
+	result.LatencyMs = chat.LatencyMs
+	result.Cost = chat.Cost
+	result.Currency = chat.Currency
+
+	// 没有断言的用例只要调用成功即视为通过
+	report := checkRunner.assertionService.Evaluate(ctx, testCase.Assertions, messages, chat.Content, newJudge(creds.Provider))
+	result.Assertions = make(models.AssertionOutcomes, len(report.Results))
+	for i, r := range report.Results {
+		result.Assertions[i] = models.AssertionOutcome{Name: r.Name, Type: r.Type, Passed: r.Passed, Score: r.Score, Reason: r.Reason}
+	}
+	result.Score = report.Score
+	if len(report.Results) == 0 {
+		result.Score = 1
+	}
+	result.Status = caseStatusFailed
+	if report.Passed {
+		result.Status = caseStatusPassed
+	}
+	return result
+}
+
+// ResumePendingChecks 重新执行服务重启前未完成的回归检查
+func ResumePendingChecks() error {
+	var ids []string
+	if err := database.DB.Model(&models.Prompt{}).Where("check_status = ?", checkStatusPending).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		startVersionCheck(id)
+	}
+	return nil
+}
+
+// loadTestCase 按 ID 加载测试用例，失败时直接写入错误响应
+func loadTestCase(c *gin.Context, id string) (*models.PromptTestCase, bool) {
+	var testCase models.PromptTestCase
+	if err := database.DB.First(&testCase, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Test case not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test case"})
+		return nil, false
+	}
+	return &testCase, true
+}
+
+// loadPromptVersion 按 ID 加载提示词版本，失败时直接写入错误响应
+func loadPromptVersion(c *gin.Context, id string) (*models.Prompt, bool) {
+	var prompt models.Prompt
+	if err := database.DB.First(&prompt, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+		return nil, false
+	}
+	return &prompt, true
+}
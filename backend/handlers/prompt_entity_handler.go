@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errPromptNameTaken 重命名时目标名称已被同项目的其他提示词占用
+var errPromptNameTaken = errors.New("prompt name already exists in this project")
+
+type PromptEntityHandler struct {
+	versionService *services.VersionService
+}
+
+func NewPromptEntityHandler() *PromptEntityHandler {
+	return &PromptEntityHandler{
+		versionService: services.NewVersionService(),
+	}
+}
+
+// versionSummary 版本摘要，不包含内容
+type versionSummary struct {
+	ID        string    `json:"id"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// labelSummary 指向该提示词某个版本的发布标签
+type labelSummary struct {
+	Label    string `json:"label"`
+	PromptID string `json:"prompt_id"`
+	Version  string `json:"version"`
+}
+
+// promptEntityItem 提示词实体及其最新版本、发布标签
+type promptEntityItem struct {
+	models.PromptEntity
+	VersionCount   int             `json:"version_count"`
+	LatestVersion  *versionSummary `json:"latest_version"`
+	Labels         []labelSummary  `json:"labels"`
+	LabeledVersion *versionSummary `json:"labeled_version,omitempty"`
+}
+
+// GetPromptEntities 获取项目下的提示词列表（每个提示词一条），附带最新版本和发布标签
+// 传入 label 时额外返回该标签指向的版本
+func (h *PromptEntityHandler) GetPromptEntities(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var entities []models.PromptEntity
+	query := database.DB.Preload("Tags").Where("project_id = ?", projectID)
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if err := query.Order("name ASC").Find(&entities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompts"})
+		return
+	}
+
+	var versions []models.Prompt
+	if err := database.DB.Select("id", "entity_id", "version", "created_at").
+		Where("project_id = ?", projectID).Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt versions"})
+		return
+	}
+	versionsByEntity := make(map[string][]models.Prompt)
+	for _, v := range versions {
+		versionsByEntity[v.EntityID] = append(versionsByEntity[v.EntityID], v)
+	}
+
+	var labels []models.PromptLabel
+	if err := database.DB.Where("project_id = ?", projectID).Order("label ASC").Find(&labels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch labels"})
+		return
+	}
+	labelsByName := make(map[string][]models.PromptLabel)
+	for _, l := range labels {
+		labelsByName[l.PromptName] = append(labelsByName[l.PromptName], l)
+	}
+
+	wantLabel := c.Query("label")
+	items := make([]promptEntityItem, 0, len(entities))
+	for _, entity := range entities {
+		item := promptEntityItem{PromptEntity: entity, Labels: []labelSummary{}}
+
+		// 版本都在回收站中的实体不显示
+		entityVersions := versionsByEntity[entity.ID]
+		if len(entityVersions) == 0 {
+			continue
+		}
+		item.VersionCount = len(entityVersions)
+		numbers := make([]string, len(entityVersions))
+		byID := make(map[string]models.Prompt, len(entityVersions))
+		for i, v := range entityVersions {
+			numbers[i] = v.Version
+			byID[v.ID] = v
+		}
+		if index := h.versionService.Latest(numbers); index >= 0 {
+			item.LatestVersion = newVersionSummary(entityVersions[index])
+		}
+
+		for _, l := range labelsByName[entity.Name] {
+			item.Labels = append(item.Labels, labelSummary{Label: l.Label, PromptID: l.PromptID, Version: l.Version})
+			if l.Label == wantLabel {
+				if v, ok := byID[l.PromptID]; ok {
+					item.LabeledVersion = newVersionSummary(v)
+				}
+			}
+		}
+
+		// 指定标签时只返回带有该标签的提示词
+		if wantLabel != "" && item.LabeledVersion == nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": len(items),
+	})
+}
+
+// GetPromptEntity 获取单个提示词实体
+func (h *PromptEntityHandler) GetPromptEntity(c *gin.Context) {
+	entity, ok := loadPromptEntity(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, entity)
+}
+
+// GetPromptEntityVersions 获取提示词的所有版本，按语义化版本从高到低排序
+func (h *PromptEntityHandler) GetPromptEntityVersions(c *gin.Context) {
+	entity, ok := loadPromptEntity(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var versions []models.Prompt
+	if err := database.DB.Preload("Tags").Where("entity_id = ?", entity.ID).Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt versions"})
+		return
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return h.versionService.CompareVersions(versions[i].Version, versions[j].Version) > 0
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  versions,
+		"total": len(versions),
+	})
+}
+
+// UpdatePromptEntity 更新提示词的名称、描述、分类和标签，重命名会同步到所有版本和发布标签
+func (h *PromptEntityHandler) UpdatePromptEntity(c *gin.Context) {
+	entity, ok := loadPromptEntity(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        string   `json:"name"`
+		Description *string  `json:"description"`
+		Category    string   `json:"category"`
+		TagIDs      []string `json:"tag_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Category != "" {
+		var categoryCount int64
+		if err := database.DB.Model(&models.Category{}).Where("name = ?", req.Category).Count(&categoryCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate category"})
+			return
+		}
+		if categoryCount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
+			return
+		}
+	}
+
+	tx := database.DB.Begin()
+	if req.Name != "" && req.Name != entity.Name {
+		if err := renamePromptEntity(tx, entity, req.Name); err != nil {
+			tx.Rollback()
+			writeRenameError(c, err)
+			return
+		}
+	}
+
+	updates := map[string]any{"updated_at": time.Now()}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Category != "" {
+		updates["category"] = req.Category
+	}
+	if err := tx.Model(entity).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prompt"})
+		return
+	}
+
+	if req.TagIDs != nil {
+		var tags []models.Tag
+		if err := tx.Where("id IN ?", req.TagIDs).Find(&tags).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+		if len(tags) != len(req.TagIDs) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ids"})
+			return
+		}
+		if err := tx.Model(entity).Association("Tags").Replace(&tags); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+			return
+		}
+	}
+	tx.Commit()
+
+	entity, ok = loadPromptEntity(c, entity.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, entity)
+}
+
+func newVersionSummary(p models.Prompt) *versionSummary {
+	return &versionSummary{ID: p.ID, Version: p.Version, CreatedAt: p.CreatedAt}
+}
+
+func loadPromptEntity(c *gin.Context, id string) (*models.PromptEntity, bool) {
+	var entity models.PromptEntity
+	if err := database.DB.Preload("Tags").First(&entity, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+		return nil, false
+	}
+	return &entity, true
+}
+
+// ensurePromptEntity 查找同项目同名称的提示词实体，不存在时创建
+func ensurePromptEntity(tx *gorm.DB, projectID, name, description, category string) (*models.PromptEntity, error) {
+	var entity models.PromptEntity
+	err := tx.Where("project_id = ? AND name = ?", projectID, name).First(&entity).Error
+	if err == nil {
+		return &entity, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	entity = models.PromptEntity{
+		ProjectID:   projectID,
+		Name:        name,
+		Description: description,
+		Category:    category,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := tx.Create(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// renamePromptEntity 重命名提示词实体，并同步更新其所有版本、发布标签及标签移动记录的名称
+func renamePromptEntity(tx *gorm.DB, entity *models.PromptEntity, name string) error {
+	var count int64
+	if err := tx.Model(&models.PromptEntity{}).
+		Where("project_id = ? AND name = ? AND id <> ?", entity.ProjectID, name, entity.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errPromptNameTaken
+	}
+
+	if err := tx.Unscoped().Model(&models.Prompt{}).Where("entity_id = ?", entity.ID).UpdateColumn("name", name).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.PromptLabel{}).
+		Where("project_id = ? AND prompt_name = ?", entity.ProjectID, entity.Name).
+		UpdateColumn("prompt_name", name).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.PromptLabelHistory{}).
+		Where("project_id = ? AND prompt_name = ?", entity.ProjectID, entity.Name).
+		UpdateColumn("prompt_name", name).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(entity).Updates(map[string]any{"name": name, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	entity.Name = name
+	return nil
+}
+
+// renamePromptVersions 按版本所属的实体重命名提示词
+func renamePromptVersions(tx *gorm.DB, prompt *models.Prompt, name string) error {
+	var entity models.PromptEntity
+	if err := tx.First(&entity, "id = ?", prompt.EntityID).Error; err != nil {
+		return err
+	}
+	return renamePromptEntity(tx, &entity, name)
+}
+
+// deletePromptEntityIfEmpty 实体下已没有任何版本（含回收站中的版本）时删除实体及其标签关联、测试用例
+func deletePromptEntityIfEmpty(tx *gorm.DB, entityID string) error {
+	var count int64
+	if err := tx.Unscoped().Model(&models.Prompt{}).Where("entity_id = ?", entityID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := deleteJoinRows(tx, "prompt_entity_tags", "prompt_entity_id", entityID); err != nil {
+		return err
+	}
+	if err := tx.Where("entity_id = ?", entityID).Delete(&models.PromptTestCase{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.PromptEntity{}, "id = ?", entityID).Error
+}
+
+func writeRenameError(c *gin.Context, err error) {
+	if err == errPromptNameTaken {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename prompt"})
+}
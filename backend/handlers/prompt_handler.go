@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"prompt-manager/database"
 	"prompt-manager/models"
 	"prompt-manager/services"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,61 +19,177 @@ import (
 )
 
 type PromptHandler struct {
-	versionService *services.VersionService
-	diffService    *services.DiffService
+	versionService   *services.VersionService
+	diffService      *services.DiffService
+	templateService  *services.TemplateService
+	assertionService *services.AssertionService
 }
 
 func NewPromptHandler() *PromptHandler {
 	return &PromptHandler{
-		versionService: services.NewVersionService(),
-		diffService:    services.NewDiffService(),
+		versionService:   services.NewVersionService(),
+		diffService:      services.NewDiffService(),
+		templateService:  services.NewTemplateService(),
+		assertionService: services.NewAssertionService(),
 	}
 }
 
-// GetPrompts 获取提示词列表
+// 提示词版本的生命周期状态
+const (
+	promptStatusDraft      = "draft"
+	promptStatusPublished  = "published"
+	promptStatusDeprecated = "deprecated"
+	promptStatusArchived   = "archived"
+)
+
+// promptStatusTransitions 各生命周期状态允许迁移到的状态
+var promptStatusTransitions = map[string][]string{
+	promptStatusDraft:      {promptStatusPublished, promptStatusArchived},
+	promptStatusPublished:  {promptStatusDeprecated, promptStatusArchived},
+	promptStatusDeprecated: {promptStatusPublished, promptStatusArchived},
+	promptStatusArchived:   {promptStatusPublished, promptStatusDeprecated},
+}
+
+// promptListSpec 提示词版本列表可排序、可选择的字段
+var promptListSpec = &listSpec{
+	table:       "prompts",
+	sortable:    []string{"name", "version", "created_at"},
+	defaultSort: "-created_at",
+	fields: map[string]string{
+		"id": "id", "project_id": "project_id", "entity_id": "entity_id", "name": "name", "version": "version",
+		"content": "content", "description": "description", "category": "category", "variables": "variables",
+		"assertions": "assertions", "check_status": "check_status", "check_accepted": "check_accepted",
+		"review_status": "review_status", "base_id": "base_id", "status": "status", "created_at": "created_at",
+		"tags": "",
+	},
+}
+
+// GetPrompts 获取提示词列表，支持分页、排序和字段选择
 func (h *PromptHandler) GetPrompts(c *gin.Context) {
 	projectID := c.Param("id")
+	q, ok := parseListQuery(c, promptListSpec)
+	if !ok {
+		return
+	}
 
-	var prompts []models.Prompt
-	query := database.DB.Preload("Tags").Where("project_id = ?", projectID)
+	query := database.DB.Model(&models.Prompt{}).Where("prompts.project_id = ?", projectID)
 
 	// 标签筛选
 	if tag := c.Query("tag"); tag != "" {
 		query = query.Joins("JOIN prompt_tags ON prompts.id = prompt_tags.prompt_id").
-			Joins("JOIN tags ON prompt_tags.tag_id = tags.id").
+			Joins("JOIN tags ON prompt_tags.tag_id = tags.id AND tags.deleted_at IS NULL").
 			Where("tags.name = ?", tag)
 	}
 
 	// 版本号筛选
 	if version := c.Query("version"); version != "" {
-		query = query.Where("version = ?", version)
+		query = query.Where("prompts.version IN ?", versionLookup(version))
 	}
 	// 名称筛选
 	if name := c.Query("name"); name != "" {
-		query = query.Where("name = ?", name)
+		query = query.Where("prompts.name = ?", name)
 	}
 	// 分类筛选
 	if category := c.Query("category"); category != "" {
-		query = query.Where("category = ?", category)
+		query = query.Where("prompts.category = ?", category)
+	}
+	// 生命周期状态筛选，可用逗号分隔多个状态
+	if status := c.Query("status"); status != "" {
+		statuses := strings.Split(status, ",")
+		for _, s := range statuses {
+			if _, ok := promptStatusTransitions[s]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status, expected draft, published, deprecated or archived"})
+				return
+			}
+		}
+		query = query.Where("prompts.status IN ?", statuses)
 	}
 
 	// 时间范围筛选
 	if startDate := c.Query("start_date"); startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
+		query = query.Where("prompts.created_at >= ?", startDate)
 	}
 	if endDate := c.Query("end_date"); endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
+		query = query.Where("prompts.created_at <= ?", endDate)
 	}
 
-	if err := query.Order("created_at DESC").Find(&prompts).Error; err != nil {
+	if q.sort == "version" {
+		h.respondPromptsByVersion(c, query, q)
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count prompts"})
+		return
+	}
+	if q.wants("tags") {
+		query = query.Preload("Tags")
+	}
+	var prompts []models.Prompt
+	if err := q.apply(query).Find(&prompts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompts"})
 		return
 	}
+	q.respond(c, prompts, total)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":  prompts,
-		"total": len(prompts),
-	})
+// respondPromptsByVersion 语义化版本无法在数据库中排序：先取出 ID 和版本号在内存中排序，再只加载当前页的记录
+func (h *PromptHandler) respondPromptsByVersion(c *gin.Context, query *gorm.DB, q *listQuery) {
+	var candidates []models.Prompt
+	if err := query.Session(&gorm.Session{}).Select("prompts.id", "prompts.version").Find(&candidates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompts"})
+		return
+	}
+	// before 判断 a 是否排在 b 之前，版本号相同时按 ID 排序
+	before := func(a, b models.Prompt) bool {
+		cmp := h.versionService.CompareVersions(a.Version, b.Version)
+		if cmp == 0 {
+			cmp = strings.Compare(a.ID, b.ID)
+		}
+		if q.desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return before(candidates[i], candidates[j]) })
+
+	start, end := 0, len(candidates)
+	if q.paged {
+		switch {
+		case q.cursor != nil && q.cursor.ID != "":
+			last := models.Prompt{ID: q.cursor.ID, Version: q.cursor.Value}
+			start = sort.Search(len(candidates), func(i int) bool { return before(last, candidates[i]) })
+		case q.cursor == nil:
+			start = min(q.offset, len(candidates))
+		}
+		end = min(start+q.pageSize, len(candidates))
+	}
+
+	ids := make([]string, 0, end-start)
+	for _, candidate := range candidates[start:end] {
+		ids = append(ids, candidate.ID)
+	}
+	pageQuery := q.selectColumns(database.DB.Model(&models.Prompt{})).Where("prompts.id IN ?", ids)
+	if q.wants("tags") {
+		pageQuery = pageQuery.Preload("Tags")
+	}
+	var rows []models.Prompt
+	if err := pageQuery.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompts"})
+		return
+	}
+	byID := make(map[string]models.Prompt, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	prompts := make([]models.Prompt, 0, len(ids))
+	for _, id := range ids {
+		if row, ok := byID[id]; ok {
+			prompts = append(prompts, row)
+		}
+	}
+	q.respond(c, prompts, int64(len(candidates)))
 }
 
 // GetPrompt 获取单个提示词
@@ -93,17 +214,41 @@ func (h *PromptHandler) CreatePrompt(c *gin.Context) {
 	projectID := c.Param("id")
 
 	var req struct {
-		Name        string   `json:"name" binding:"required"`
-		Content     string   `json:"content" binding:"required"`
-		TagIDs      []string `json:"tag_ids"`
-		Category    string   `json:"category"`
-		Description string   `json:"description"`
+		Name        string                 `json:"name" binding:"required"`
+		Content     string                 `json:"content" binding:"required"`
+		TagIDs      []string               `json:"tag_ids"`
+		Category    string                 `json:"category"`
+		Description string                 `json:"description"`
+		Variables   models.PromptVariables `json:"variables"`
+		Assertions  models.Assertions      `json:"assertions"`
+		Version     string                 `json:"version"` // 可选，指定版本号（如 2.0.0-rc.1），默认在最高版本上递增 patch
+		Submit      bool                   `json:"submit"`  // 项目需要审批时，是否直接提交审批
+		Status      string                 `json:"status"`  // 可选，draft 或 published（默认）
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	status, ok := initialPromptStatus(c, req.Status)
+	if !ok {
+		return
+	}
+	if err := h.templateService.ValidateSchema(req.Variables); err != nil {
+		writeRenderError(c, err)
+		return
+	}
+	if err := h.assertionService.Validate(req.Assertions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Version != "" && !h.versionService.IsValidVersion(req.Version) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version, expected semantic version such as 1.2.0 or 2.0.0-rc.1"})
+		return
+	}
+	if req.Version != "" {
+		req.Version = services.NormalizeVersion(req.Version)
+	}
 	// 分类必须存在于分类库
 	if req.Category == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "category is required"})
@@ -119,34 +264,77 @@ func (h *PromptHandler) CreatePrompt(c *gin.Context) {
 		return
 	}
 
-	// 获取该名称下最新的版本号
-	var lastPrompt models.Prompt
-	var newVersion string
-	if err := database.DB.Where("project_id = ? AND name = ?", projectID, req.Name).Order("created_at DESC").First(&lastPrompt).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	if req.Version != "" {
+		taken, err := h.versionTaken(database.DB, projectID, req.Name, req.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt versions"})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "Version " + req.Version + " already exists for this prompt"})
+			return
+		}
+	}
+
+	// 未指定版本号时，在该名称下最高的版本号上递增；回收站中的版本仍占用版本号，恢复时才不会冲突
+	newVersion := req.Version
+	var baseID string
+	if newVersion == "" {
+		lastPrompt, err := findPromptVersion(database.DB.Unscoped().Where("project_id = ? AND name = ?", projectID, req.Name), h.versionService.Highest)
+		switch {
+		case err == gorm.ErrRecordNotFound:
 			newVersion = "1.0.0"
-		} else {
+		case err != nil:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch last prompt"})
 			return
+		default:
+			newVersion = h.versionService.GenerateNextVersion(lastPrompt.Version, "patch")
+			baseID = lastPrompt.ID
 		}
-	} else {
-		newVersion = h.versionService.GenerateNextVersion(lastPrompt.Version, "patch")
+	}
+	reviewStatus, err := initialReviewStatus(database.DB, projectID, req.Submit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
 	}
 
 	// 创建新提示词
 	prompt := models.Prompt{
-		ProjectID:   projectID,
-		Name:        req.Name,
-		Version:     newVersion,
-		Content:     req.Content,
-		Category:    req.Category,
-		Description: req.Description,
-		CreatedAt:   time.Now(),
+		ProjectID:    projectID,
+		Name:         req.Name,
+		Version:      newVersion,
+		Content:      req.Content,
+		Category:     req.Category,
+		Description:  req.Description,
+		Variables:    h.templateService.DetectSchema(req.Content, req.Variables),
+		Assertions:   req.Assertions,
+		ReviewStatus: reviewStatus,
+		BaseID:       baseID,
+		Status:       status,
+		CreatedAt:    time.Now(),
 	}
 
 	tx := database.DB.Begin()
+	entity, err := ensurePromptEntity(tx, projectID, req.Name, req.Description, req.Category)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prompt"})
+		return
+	}
+	prompt.EntityID = entity.ID
+	// 已有测试用例的提示词，新版本需通过回归检查
+	if prompt.CheckStatus, err = pendingCheckStatus(tx, entity.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test cases"})
+		return
+	}
+
 	if err := tx.Create(&prompt).Error; err != nil {
 		tx.Rollback()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Version " + newVersion + " already exists for this prompt"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prompt"})
 		return
 	}
@@ -183,6 +371,7 @@ func (h *PromptHandler) CreatePrompt(c *gin.Context) {
 	history := models.PromptHistory{
 		PromptID:   prompt.ID,
 		Operation:  "create",
+		Author:     requestActor(c, ""),
 		NewContent: req.Content,
 		CreatedAt:  time.Now(),
 	}
@@ -193,6 +382,9 @@ func (h *PromptHandler) CreatePrompt(c *gin.Context) {
 	}
 
 	tx.Commit()
+	if prompt.CheckStatus == checkStatusPending {
+		startVersionCheck(prompt.ID)
+	}
 	c.JSON(http.StatusCreated, prompt)
 }
 
@@ -201,18 +393,54 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		Name        string   `json:"name"`
-		Content     string   `json:"content"`
-		Description string   `json:"description"`
-		Category    string   `json:"category"`
-		TagIDs      []string `json:"tag_ids"`
-		Bump        string   `json:"bump"`         // major|minor|patch|none|keep_version
-		KeepVersion bool     `json:"keep_version"` // 是否保持当前版本号不变
+		Name        string                 `json:"name"`
+		Content     string                 `json:"content"`
+		Description string                 `json:"description"`
+		Category    string                 `json:"category"`
+		TagIDs      []string               `json:"tag_ids"`
+		Bump        string                 `json:"bump"`         // major|minor|patch|none|keep_version
+		KeepVersion bool                   `json:"keep_version"` // 是否保持当前版本号不变
+		Variables   models.PromptVariables `json:"variables"`    // 为空时沿用当前版本的变量定义
+		Assertions  models.Assertions      `json:"assertions"`   // 为空时沿用当前版本的断言
+		Version     string                 `json:"version"`      // 可选，指定新版本的版本号，优先于 bump
+		Submit      bool                   `json:"submit"`       // 项目需要审批时，是否将新版本直接提交审批
+		Status      string                 `json:"status"`       // 可选，新版本的状态：draft 或 published（默认）
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	status, ok := initialPromptStatus(c, req.Status)
+	if !ok {
+		return
+	}
+	if err := h.templateService.ValidateSchema(req.Variables); err != nil {
+		writeRenderError(c, err)
+		return
+	}
+	if err := h.assertionService.Validate(req.Assertions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Version != "" && !h.versionService.IsValidVersion(req.Version) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version, expected semantic version such as 1.2.0 or 2.0.0-rc.1"})
+		return
+	}
+	if req.Version != "" {
+		req.Version = services.NormalizeVersion(req.Version)
+	}
+	// 分类必须存在于分类库，需在同步到提示词实体和写入版本之前校验
+	if req.Category != "" {
+		var categoryCount int64
+		if err := database.DB.Model(&models.Category{}).Where("name = ?", req.Category).Count(&categoryCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate category"})
+			return
+		}
+		if categoryCount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
+			return
+		}
+	}
 
 	var existing models.Prompt
 	if err := database.DB.Preload("Tags").First(&existing, "id = ?", id).Error; err != nil {
@@ -232,8 +460,52 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 		bump = "patch"
 	}
 
+	declaredVariables := existing.Variables
+	if req.Variables != nil {
+		declaredVariables = req.Variables
+	}
+	assertions := existing.Assertions
+	if req.Assertions != nil {
+		assertions = req.Assertions
+	}
+
+	// 内容变化时需重新执行回归检查；项目需要审批时，新内容需审批后才能发布
+	checkStatus, reviewStatus := "", existing.ReviewStatus
+	if contentChanged {
+		status, err := pendingCheckStatus(database.DB, existing.EntityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test cases"})
+			return
+		}
+		checkStatus = status
+
+		if req.KeepVersion {
+			reviewStatus, err = editedReviewStatus(database.DB, &existing)
+		} else {
+			reviewStatus, err = initialReviewStatus(database.DB, existing.ProjectID, req.Submit)
+		}
+		if errors.Is(err, errApprovedVersionLocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+			return
+		}
+	}
+
 	tx := database.DB.Begin()
 
+	// 分类属于整个提示词，同步到提示词实体
+	if req.Category != "" && req.Category != existing.Category && existing.EntityID != "" {
+		if err := tx.Model(&models.PromptEntity{}).Where("id = ?", existing.EntityID).
+			Updates(map[string]any{"category": req.Category, "updated_at": time.Now()}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prompt"})
+			return
+		}
+	}
+
 	// 如果内容变化但用户选择保持版本号不变，直接更新当前记录
 	if contentChanged && req.KeepVersion {
 		// 保存旧内容用于历史记录
@@ -241,26 +513,24 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 
 		// 直接更新当前记录的content，不创建新版本
 		existing.Content = req.Content
-		// 更新名称
+		existing.Variables = h.templateService.DetectSchema(req.Content, declaredVariables)
+		existing.Assertions = assertions
+		existing.CheckStatus = checkStatus
+		existing.CheckAccepted = false
+		existing.ReviewStatus = reviewStatus
+		// 更新名称（同步到所有版本）
 		if req.Name != "" && req.Name != existing.Name {
+			if err := renamePromptVersions(tx, &existing, req.Name); err != nil {
+				tx.Rollback()
+				writeRenameError(c, err)
+				return
+			}
 			existing.Name = req.Name
 		}
 		if req.Description != "" && req.Description != existing.Description {
 			existing.Description = req.Description
 		}
 		if req.Category != "" && req.Category != existing.Category {
-			// 分类必须存在
-			var categoryCount int64
-			if err := database.DB.Model(&models.Category{}).Where("name = ?", req.Category).Count(&categoryCount).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate category"})
-				return
-			}
-			if categoryCount == 0 {
-				tx.Rollback()
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
-				return
-			}
 			existing.Category = req.Category
 		}
 
@@ -281,6 +551,10 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 
 		if err := tx.Save(&existing).Error; err != nil {
 			tx.Rollback()
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Version " + existing.Version + " already exists for prompt " + existing.Name})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prompt"})
 			return
 		}
@@ -289,6 +563,7 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 		history := models.PromptHistory{
 			PromptID:   existing.ID,
 			Operation:  "update_keep_version",
+			Author:     requestActor(c, ""),
 			OldContent: oldContent,
 			NewContent: req.Content,
 			CreatedAt:  time.Now(),
@@ -300,21 +575,56 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 		}
 
 		tx.Commit()
+		if existing.CheckStatus == checkStatusPending {
+			startVersionCheck(existing.ID)
+		}
 		c.JSON(http.StatusOK, existing)
 		return
 	}
 
 	if contentChanged {
-		// 创建新版本记录
-		newVersion := h.versionService.GenerateNextVersion(existing.Version, bump)
+		// 重命名作用于整个提示词，先同步所有已有版本
+		name := existing.Name
+		if req.Name != "" && req.Name != existing.Name {
+			if err := renamePromptVersions(tx, &existing, req.Name); err != nil {
+				tx.Rollback()
+				writeRenameError(c, err)
+				return
+			}
+			name = req.Name
+		}
+
+		// 创建新版本记录，版本号在该名称下最高的版本上递增，避免编辑旧版本时与已有版本冲突
+		newVersion := req.Version
+		if newVersion == "" {
+			base := existing.Version
+			lastPrompt, err := findPromptVersion(tx.Unscoped().Where("project_id = ? AND name = ?", existing.ProjectID, name), h.versionService.Highest)
+			if err != nil && err != gorm.ErrRecordNotFound {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch last prompt"})
+				return
+			}
+			if err == nil && h.versionService.CompareVersions(lastPrompt.Version, base) > 0 {
+				base = lastPrompt.Version
+			}
+			newVersion = h.versionService.GenerateNextVersion(base, bump)
+		} else {
+			taken, err := h.versionTaken(tx, existing.ProjectID, name, newVersion)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt versions"})
+				return
+			}
+			if taken {
+				tx.Rollback()
+				c.JSON(http.StatusConflict, gin.H{"error": "Version " + newVersion + " already exists for prompt " + name})
+				return
+			}
+		}
 		newPrompt := models.Prompt{
-			ProjectID: existing.ProjectID,
-			Name: func() string {
-				if req.Name != "" {
-					return req.Name
-				}
-				return existing.Name
-			}(),
+			ProjectID:   existing.ProjectID,
+			EntityID:    existing.EntityID,
+			Name:        name,
 			Version:     newVersion,
 			Content:     req.Content,
 			Description: req.Description,
@@ -324,10 +634,20 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 				}
 				return existing.Category
 			}(),
-			CreatedAt: time.Now(),
+			Variables:    h.templateService.DetectSchema(req.Content, declaredVariables),
+			Assertions:   assertions,
+			CheckStatus:  checkStatus,
+			ReviewStatus: reviewStatus,
+			BaseID:       existing.ID,
+			Status:       status,
+			CreatedAt:    time.Now(),
 		}
 		if err := tx.Create(&newPrompt).Error; err != nil {
 			tx.Rollback()
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Version " + newVersion + " already exists for prompt " + name})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create new version"})
 			return
 		}
@@ -349,6 +669,7 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 		history := models.PromptHistory{
 			PromptID:   newPrompt.ID,
 			Operation:  "update",
+			Author:     requestActor(c, ""),
 			OldContent: existing.Content,
 			NewContent: req.Content,
 			CreatedAt:  time.Now(),
@@ -359,14 +680,22 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 			return
 		}
 		tx.Commit()
+		if newPrompt.CheckStatus == checkStatusPending {
+			startVersionCheck(newPrompt.ID)
+		}
 		c.JSON(http.StatusOK, newPrompt)
 		return
 	}
 
 	// 仅更新元信息（描述、分类、标签、名称）
 	updated := false
-	// 如果请求中包含名称字段且名称有变化，则更新名称
+	// 如果请求中包含名称字段且名称有变化，则重命名整个提示词
 	if req.Name != "" && req.Name != existing.Name {
+		if err := renamePromptVersions(tx, &existing, req.Name); err != nil {
+			tx.Rollback()
+			writeRenameError(c, err)
+			return
+		}
 		existing.Name = req.Name
 		updated = true
 	}
@@ -375,21 +704,17 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 		updated = true
 	}
 	if req.Category != "" {
-		// 分类必须存在
-		var categoryCount int64
-		if err := database.DB.Model(&models.Category{}).Where("name = ?", req.Category).Count(&categoryCount).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate category"})
-			return
-		}
-		if categoryCount == 0 {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
-			return
-		}
 		existing.Category = req.Category
 		updated = true
 	}
+	if req.Variables != nil {
+		existing.Variables = h.templateService.DetectSchema(existing.Content, req.Variables)
+		updated = true
+	}
+	if req.Assertions != nil {
+		existing.Assertions = req.Assertions
+		updated = true
+	}
 	if len(req.TagIDs) > 0 {
 		var tags []models.Tag
 		if err := tx.Where("id IN ?", req.TagIDs).Find(&tags).Error; err != nil {
@@ -407,6 +732,10 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 	if updated {
 		if err := tx.Save(&existing).Error; err != nil {
 			tx.Rollback()
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Version " + existing.Version + " already exists for prompt " + existing.Name})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prompt"})
 			return
 		}
@@ -415,32 +744,139 @@ func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
 	c.JSON(http.StatusOK, existing)
 }
 
-// DeletePrompt 删除单个提示词版本
+// DeletePrompt 将单个提示词版本移入回收站
 func (h *PromptHandler) DeletePrompt(c *gin.Context) {
 	id := c.Param("id")
 
-	tx := database.DB.Begin()
-	// 删除标签关联
-	if err := tx.Exec("DELETE FROM prompt_tags WHERE prompt_id = ?", id).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prompt tags"})
+	// 被发布标签引用的版本不允许删除，需先移动或移除标签
+	var labelCount int64
+	if err := database.DB.Model(&models.PromptLabel{}).Where("prompt_id = ?", id).Count(&labelCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check labels"})
 		return
 	}
-	// 删除历史记录由外键级联处理，但保险起见：
-	if err := tx.Exec("DELETE FROM prompt_histories WHERE prompt_id = ?", id).Error; err != nil {
-		// 如果表名为 prompt_history，兼容：
-		_ = tx.Exec("DELETE FROM prompt_history WHERE prompt_id = ?", id).Error
+	if labelCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prompt version is referenced by a release label"})
+		return
 	}
-	// 删除提示词
-	if err := tx.Delete(&models.Prompt{}, "id = ?", id).Error; err != nil {
-		tx.Rollback()
+
+	// 移入回收站，标签关联和历史记录保留到彻底删除，恢复后仍然可用
+	if err := database.DB.Delete(&models.Prompt{}, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prompt"})
 		return
 	}
-	tx.Commit()
+	cancelVersionCheck(id)
 	c.JSON(http.StatusOK, gin.H{"message": "Prompt deleted successfully"})
 }
 
+// purgePrompts 彻底删除提示词版本及其标签关联、历史记录、检查结果和审批记录，
+// 实体下已没有任何版本时一并删除实体
+func purgePrompts(tx *gorm.DB, promptIDs []string) error {
+	if len(promptIDs) == 0 {
+		return nil
+	}
+	var entityIDs []string
+	if err := tx.Unscoped().Model(&models.Prompt{}).Where("id IN ? AND entity_id <> ''", promptIDs).
+		Distinct().Pluck("entity_id", &entityIDs).Error; err != nil {
+		return err
+	}
+	if err := deleteJoinRows(tx, "prompt_tags", "prompt_id", promptIDs); err != nil {
+		return err
+	}
+	if err := tx.Where("prompt_id IN ?", promptIDs).Delete(&models.PromptHistory{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("prompt_id IN ?", promptIDs).Delete(&models.PromptCheckResult{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("prompt_id IN ?", promptIDs).Delete(&models.PromptReview{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("id IN ?", promptIDs).Delete(&models.Prompt{}).Error; err != nil {
+		return err
+	}
+	for _, entityID := range entityIDs {
+		if err := deletePromptEntityIfEmpty(tx, entityID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdatePromptStatus 变更提示词版本的生命周期状态
+func (h *PromptHandler) UpdatePromptStatus(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := promptStatusTransitions[req.Status]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status, expected draft, published, deprecated or archived"})
+		return
+	}
+
+	current := prompt.Status
+	if current == "" {
+		current = promptStatusPublished
+	}
+	if current == req.Status {
+		c.JSON(http.StatusOK, prompt)
+		return
+	}
+	allowed := false
+	for _, next := range promptStatusTransitions[current] {
+		allowed = allowed || next == req.Status
+	}
+	if !allowed {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("cannot change status from %s to %s", current, req.Status)})
+		return
+	}
+
+	switch req.Status {
+	case promptStatusPublished:
+		// 需要审批的版本通过审批后才能发布
+		if prompt.ReviewStatus != "" && prompt.ReviewStatus != reviewStatusApproved {
+			c.JSON(http.StatusConflict, gin.H{"error": "Prompt version has not been approved"})
+			return
+		}
+	case promptStatusArchived:
+		// 被发布标签引用的版本不允许归档，需先移动或移除标签
+		var labelCount int64
+		if err := database.DB.Model(&models.PromptLabel{}).Where("prompt_id = ?", prompt.ID).Count(&labelCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check labels"})
+			return
+		}
+		if labelCount > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Prompt version is referenced by a release label"})
+			return
+		}
+	}
+
+	if err := database.DB.Model(prompt).Update("status", req.Status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prompt status"})
+		return
+	}
+	c.JSON(http.StatusOK, prompt)
+}
+
+// initialPromptStatus 校验新版本的生命周期状态，只能创建为草稿或直接发布，失败时直接写入错误响应
+func initialPromptStatus(c *gin.Context, status string) (string, bool) {
+	switch status {
+	case "":
+		return promptStatusPublished, true
+	case promptStatusDraft, promptStatusPublished:
+		return status, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status, new versions can only be draft or published"})
+	return "", false
+}
+
 // GetPromptDiff 获取版本差异
 func (h *PromptHandler) GetPromptDiff(c *gin.Context) {
 	id := c.Param("id")
@@ -488,109 +924,331 @@ func (h *PromptHandler) RollbackPrompt(c *gin.Context) {
 		return
 	}
 
-	// 生成新版本号
-	var lastPrompt models.Prompt
-	var newVersion string
-	if err := database.DB.Where("project_id = ? AND name = ?", sourcePrompt.ProjectID, sourcePrompt.Name).
-		Order("created_at DESC").First(&lastPrompt).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			newVersion = "1.0.0"
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch last prompt"})
-			return
-		}
-	} else {
-		newVersion = h.versionService.GenerateNextVersion(lastPrompt.Version, "patch")
+	newPrompt, ok := createDerivedVersion(c, h.versionService, &sourcePrompt, sourcePrompt.Content,
+		fmt.Sprintf("Rollback to version %s", sourcePrompt.Version), sourcePrompt.Variables, "rollback")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newPrompt)
+}
+
+// createDerivedVersion 基于已有版本创建一个新版本（回滚、从历史恢复），
+// 复制标签并记录历史，失败时直接写入错误响应
+func createDerivedVersion(c *gin.Context, versionService *services.VersionService, source *models.Prompt, content, description string, variables models.PromptVariables, operation string) (*models.Prompt, bool) {
+	// 在该名称下最高的版本号上递增
+	var newVersion, lastContent, baseID string
+	lastPrompt, err := findPromptVersion(database.DB.Unscoped().Where("project_id = ? AND name = ?", source.ProjectID, source.Name), versionService.Highest)
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		newVersion = "1.0.0"
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch last prompt"})
+		return nil, false
+	default:
+		newVersion = versionService.GenerateNextVersion(lastPrompt.Version, "patch")
+		lastContent = lastPrompt.Content
+		baseID = lastPrompt.ID
+	}
+	reviewStatus, err := initialReviewStatus(database.DB, source.ProjectID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return nil, false
 	}
 
-	// 创建回滚版本
 	newPrompt := models.Prompt{
-		ProjectID:   sourcePrompt.ProjectID,
-		Name:        sourcePrompt.Name,
-		Version:     newVersion,
-		Content:     sourcePrompt.Content,
-		Category:    sourcePrompt.Category,
-		Description: fmt.Sprintf("Rollback to version %s", sourcePrompt.Version),
-		CreatedAt:   time.Now(),
+		ProjectID:    source.ProjectID,
+		EntityID:     source.EntityID,
+		Name:         source.Name,
+		Version:      newVersion,
+		Content:      content,
+		Category:     source.Category,
+		Description:  description,
+		Variables:    variables,
+		Assertions:   source.Assertions,
+		ReviewStatus: reviewStatus,
+		BaseID:       baseID,
+		Status:       promptStatusPublished,
+		CreatedAt:    time.Now(),
+	}
+	if newPrompt.CheckStatus, err = pendingCheckStatus(database.DB, source.EntityID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test cases"})
+		return nil, false
 	}
 
 	tx := database.DB.Begin()
 	if err := tx.Create(&newPrompt).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rollback prompt"})
-		return
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create " + operation + " prompt"})
+		return nil, false
 	}
 
 	// 复制标签
 	var tags []models.Tag
-	if err := tx.Model(&sourcePrompt).Association("Tags").Find(&tags); err == nil && len(tags) > 0 {
+	if err := tx.Model(source).Association("Tags").Find(&tags); err == nil && len(tags) > 0 {
 		if err := tx.Model(&newPrompt).Association("Tags").Append(&tags); err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy tags"})
-			return
+			return nil, false
 		}
 	}
 
 	// 记录操作历史
 	history := models.PromptHistory{
 		PromptID:   newPrompt.ID,
-		Operation:  "rollback",
-		OldContent: "",
-		NewContent: sourcePrompt.Content,
+		Operation:  operation,
+		Author:     requestActor(c, ""),
+		OldContent: lastContent,
+		NewContent: content,
 		CreatedAt:  time.Now(),
 	}
 	if err := tx.Create(&history).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create history"})
-		return
+		return nil, false
 	}
 
 	tx.Commit()
-	c.JSON(http.StatusOK, newPrompt)
+	if newPrompt.CheckStatus == checkStatusPending {
+		startVersionCheck(newPrompt.ID)
+	}
+	return &newPrompt, true
+}
+
+// versionLookup 精确查找版本号时匹配的值：规范化后的版本号和原样输入的版本号，
+// 后者用于匹配规范化之前保存的版本号（如 v1.0.0）和去重时追加了构建元数据的版本号（如 1.0.0+3f2a9c1d）
+func versionLookup(version string) []string {
+	raw := strings.TrimSpace(version)
+	normalized := services.NormalizeVersion(version)
+	if raw == normalized {
+		return []string{normalized}
+	}
+	return []string{normalized, raw}
+}
+
+// pickExactVersion 配合 versionLookup 使用，优先选择与输入完全一致的版本号
+func pickExactVersion(version string) func(versions []string) int {
+	raw := strings.TrimSpace(version)
+	normalized := services.NormalizeVersion(version)
+	return func(versions []string) int {
+		index := -1
+		for i, v := range versions {
+			if v == raw {
+				return i
+			}
+			if v == normalized && index < 0 {
+				index = i
+			}
+		}
+		return index
+	}
+}
+
+// versionTaken 判断同一提示词下是否已有语义化版本相同的版本（包括回收站中的版本），
+// 规范化之前保存的版本号（如 v1.0.0）不受唯一索引约束，需要逐一比较
+func (h *PromptHandler) versionTaken(tx *gorm.DB, projectID, name, version string) (bool, error) {
+	var versions []string
+	if err := tx.Unscoped().Model(&models.Prompt{}).Where("project_id = ? AND name = ?", projectID, name).
+		Pluck("version", &versions).Error; err != nil {
+		return false, err
+	}
+	for _, v := range versions {
+		if h.versionService.CompareVersions(v, version) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findPromptVersion 按语义化版本从查询结果中挑选一条记录，pick 返回选中版本的下标，
+// 没有可选记录时返回 gorm.ErrRecordNotFound
+func findPromptVersion(query *gorm.DB, pick func(versions []string) int) (*models.Prompt, error) {
+	var candidates []models.Prompt
+	if err := query.Select("prompts.id", "prompts.version").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		versions[i] = candidate.Version
+	}
+	index := pick(versions)
+	if index < 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var prompt models.Prompt
+	// 候选记录已按 query 的条件筛选过，这里按 ID 取回时不再排除回收站中的版本
+	if err := query.Session(&gorm.Session{NewDB: true}).Unscoped().First(&prompt, "id = ?", candidates[index].ID).Error; err != nil {
+		return nil, err
+	}
+	return &prompt, nil
 }
 
 // GetSDKPrompt 获取提示词内容（SDK专用接口）
 func (h *PromptHandler) GetSDKPrompt(c *gin.Context) {
-	projectID := c.Param("id")
-	name := c.Query("name")
-	version := c.Query("version")
-	tag := c.Query("tag")
+	label := c.Query("label")
+	hidden := sdkHiddenStatuses(c.Query("include_drafts") == "true", c.Query("include_archived") == "true")
+	prompt, ok := h.resolveSDKPrompt(c, c.Param("id"), c.Query("name"), c.Query("version"), label, c.Query("tag"), hidden)
+	if !ok {
+		return
+	}
 
+	resp := gin.H{
+		"content": prompt.Content,
+		"version": prompt.Version,
+	}
+	if label != "" {
+		resp["label"] = label
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RenderSDKPrompt 在服务端渲染提示词模板变量（SDK专用接口）
+func (h *PromptHandler) RenderSDKPrompt(c *gin.Context) {
+	var req struct {
+		Name      string         `json:"name" binding:"required"`
+		Version   string         `json:"version"`
+		Label     string         `json:"label"`
+		Tag       string         `json:"tag"`
+		Variables map[string]any `json:"variables"`
+
+		IncludeDrafts   bool `json:"include_drafts"`
+		IncludeArchived bool `json:"include_archived"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hidden := sdkHiddenStatuses(req.IncludeDrafts, req.IncludeArchived)
+	prompt, ok := h.resolveSDKPrompt(c, c.Param("id"), req.Name, req.Version, req.Label, req.Tag, hidden)
+	if !ok {
+		return
+	}
+
+	content, err := h.templateService.Render(prompt.Content, prompt.Variables, req.Variables)
+	if err != nil {
+		writeRenderError(c, err)
+		return
+	}
+
+	resp := gin.H{
+		"content": content,
+		"version": prompt.Version,
+	}
+	if req.Label != "" {
+		resp["label"] = req.Label
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// sdkHiddenStatuses SDK 默认跳过草稿和已归档的版本，调用方可显式包含
+func sdkHiddenStatuses(includeDrafts, includeArchived bool) []string {
+	hidden := []string{}
+	if !includeDrafts {
+		hidden = append(hidden, promptStatusDraft)
+	}
+	if !includeArchived {
+		hidden = append(hidden, promptStatusArchived)
+	}
+	return hidden
+}
+
+// resolveSDKPrompt 按名称、版本号、发布标签或标签查找提示词版本，失败时直接写入错误响应
+// hidden 中状态的版本不会被返回；返回已弃用的版本时写入弃用警告响应头
+func (h *PromptHandler) resolveSDKPrompt(c *gin.Context, projectID, name, version, label, tag string, hidden []string) (*models.Prompt, bool) {
+	prompt, ok := h.findSDKPrompt(c, projectID, name, version, label, tag, hidden)
+	if ok && prompt.Status == promptStatusDeprecated {
+		c.Header("Deprecation", "true")
+		c.Header("Warning", fmt.Sprintf(`299 - "prompt %s version %s is deprecated"`, prompt.Name, prompt.Version))
+	}
+	return prompt, ok
+}
+
+func (h *PromptHandler) findSDKPrompt(c *gin.Context, projectID, name, version, label, tag string, hidden []string) (*models.Prompt, bool) {
 	if name == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt name is required"})
-		return
+		return nil, false
+	}
+	if label != "" && version != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version and label cannot be used together"})
+		return nil, false
 	}
 
 	var prompt models.Prompt
-	query := database.DB.Where("project_id = ? AND name = ?", projectID, name)
+
+	// 按发布标签获取
+	if label != "" {
+		var promptLabel models.PromptLabel
+		if err := database.DB.Where("project_id = ? AND prompt_name = ? AND label = ?", projectID, name, label).First(&promptLabel).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+				return nil, false
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch label"})
+			return nil, false
+		}
+		// 发布标签只能指向已审批的版本
+		query := database.DB.Where("(review_status IS NULL OR review_status IN ?)", servableReviewStatuses)
+		if len(hidden) > 0 {
+			query = query.Where("(status IS NULL OR status NOT IN ?)", hidden)
+		}
+		if err := query.First(&prompt, "id = ?", promptLabel.PromptID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+				return nil, false
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+			return nil, false
+		}
+		return &prompt, true
+	}
+
+	// 未审批的版本对 SDK 不可见
+	query := database.DB.Where("project_id = ? AND name = ?", projectID, name).
+		Where("(prompts.review_status IS NULL OR prompts.review_status IN ?)", servableReviewStatuses)
+	if len(hidden) > 0 {
+		query = query.Where("(prompts.status IS NULL OR prompts.status NOT IN ?)", hidden)
+	}
 
 	// 标签筛选
 	if tag != "" {
 		query = query.Joins("JOIN prompt_tags ON prompts.id = prompt_tags.prompt_id").
-			Joins("JOIN tags ON prompt_tags.tag_id = tags.id").
+			Joins("JOIN tags ON prompt_tags.tag_id = tags.id AND tags.deleted_at IS NULL").
 			Where("tags.name = ?", tag)
 	}
 
-	// 版本号筛选
-	if version != "" {
-		query = query.Where("version = ?", version)
+	// 未指定版本或使用版本范围时，跳过未通过回归检查且未被强制接受的版本
+	if version == "" || services.IsVersionRange(version) {
+		query = query.Where("(prompts.check_status IS NULL OR prompts.check_status IN ? OR prompts.check_accepted = ?)", servableCheckStatuses, true)
+	}
+
+	// 未指定版本时取最新的正式版本；版本范围（如 ^1.2、~1.4.0）取满足范围的最高版本；否则精确匹配
+	pick := h.versionService.Latest
+	switch {
+	case version == "":
+	case services.IsVersionRange(version):
+		versionRange, _ := services.ParseVersionRange(version)
+		pick = func(versions []string) int {
+			return h.versionService.MaxSatisfying(versions, versionRange)
+		}
+	default:
+		query = query.Where("version IN ?", versionLookup(version))
+		pick = pickExactVersion(version)
 	}
 
-	// 如果没有指定版本，默认取最新版本（按创建时间倒序）
-	// 注意：如果需要严格的语义版本排序，可能需要把所有版本查出来在内存排序，
-	// 或者确保数据库中的 created_at 严格对应版本发布顺序。通常 created_at 是够用的。
-	if err := query.Order("created_at DESC").First(&prompt).Error; err != nil {
+	found, err := findPromptVersion(query, pick)
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
-			return
+			return nil, false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"content": prompt.Content,
-	})
+	return found, true
 }
 
 // TestPrompt 测试提示词
@@ -603,81 +1261,288 @@ func (h *PromptHandler) TestPrompt(c *gin.Context) {
 		Temperature *float64                 `json:"temperature"`
 		TopP        *float64                 `json:"top_p"`
 		MaxTokens   int                      `json:"max_tokens"`
+		PromptID    string                   `json:"prompt_id"`  // 可选，用于获取变量定义
+		Variables   map[string]any           `json:"variables"`  // 传入时在服务端渲染模板变量
+		Assertions  models.Assertions        `json:"assertions"` // 为空时使用 prompt_id 对应版本保存的断言
+		Targets     []testTarget             `json:"targets"`    // 传入时并行调用多个模型对比，忽略 provider 和 model
 	}
 	var req TestPromptRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	provider := services.ProviderType(req.Provider)
-	if provider == "" {
-		provider = services.ProviderAliyun
+	if err := h.assertionService.Validate(req.Assertions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	apiKeyKey := services.GetProviderSettingsKey(provider)
-	apiURLKey := services.GetProviderURLKey(provider)
-	modelKey := services.GetProviderModelKey(provider)
-
-	var apiKeySetting models.Setting
-	database.DB.Where("`key` = ?", apiKeyKey).First(&apiKeySetting)
-
-	var apiURLSetting models.Setting
-	database.DB.Where("`key` = ?", apiURLKey).First(&apiURLSetting)
-
-	var modelSetting models.Setting
-	database.DB.Where("`key` = ?", modelKey).First(&modelSetting)
+	// 与 SDK 渲染接口保持一致的服务端变量渲染
+	if req.Variables != nil || req.PromptID != "" {
+		messages, ok := h.renderTestMessages(c, req.PromptID, req.Messages, req.Variables)
+		if !ok {
+			return
+		}
+		req.Messages = messages
+	}
+	if req.Assertions == nil && req.PromptID != "" {
+		var prompt models.Prompt
+		if err := database.DB.Select("assertions").First(&prompt, "id = ?", req.PromptID).Error; err == nil {
+			req.Assertions = prompt.Assertions
+		}
+	}
 
-	if apiKeySetting.Value == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s API Key not configured", provider)})
+	if len(req.Targets) > 0 {
+		defaults := services.ChatOptions{Temperature: req.Temperature, TopP: req.TopP, MaxTokens: req.MaxTokens}
+		h.testTargets(c, req.Targets, defaults, req.PromptID, req.Messages, req.Stream, req.Assertions)
 		return
 	}
 
-	model := req.Model
-	if model == "" {
-		model = modelSetting.Value
-		if model == "" {
-			providerObj, err := services.GetProvider(provider)
-			if err == nil {
-				model = providerObj.GetDefaultModel()
-			} else {
-				model = "qwen-turbo"
-			}
-		}
+	creds, ok := resolveProviderCredentials(c, req.Provider, req.Model)
+	if !ok {
+		return
 	}
 
 	options := services.ChatOptions{
-		Model:       model,
+		Model:       creds.Model,
 		Temperature: req.Temperature,
 		TopP:        req.TopP,
 		MaxTokens:   req.MaxTokens,
 	}
 
+	run := newTestRun(c, runKindTest, req.PromptID, creds, options, req.Messages, req.Stream)
+	start := time.Now()
+
 	if req.Stream {
 		c.Header("Content-Type", "text/event-stream")
 		c.Header("Cache-Control", "no-cache")
 		c.Header("Connection", "keep-alive")
 		c.Header("Transfer-Encoding", "chunked")
 
-		err := services.CallModelStream(provider, apiKeySetting.Value, apiURLSetting.Value, options, req.Messages, func(text string) error {
+		result, err := services.CallModelStream(c.Request.Context(), creds.Provider, creds.APIKey, creds.APIURL, options, req.Messages, func(text string) error {
 			data := map[string]string{"text": text}
 			jsonData, _ := json.Marshal(data)
 			c.SSEvent("message", string(jsonData))
 			c.Writer.Flush()
 			return nil
 		})
+		finishTestRun(run, result, err, start)
 
 		if err != nil {
-			c.SSEvent("error", err.Error())
+			writeProviderStreamError(c, err)
+			return
+		}
+		writeStreamMetrics(c, result, run.ID)
+		if len(req.Assertions) > 0 {
+			report := h.assertionService.Evaluate(c.Request.Context(), req.Assertions, req.Messages, result.Content, newJudge(creds.Provider))
+			jsonData, _ := json.Marshal(gin.H{"assertions": report})
+			c.SSEvent("assertions", string(jsonData))
+			c.Writer.Flush()
 		}
 		return
 	}
 
-	response, err := services.CallModel(provider, apiKeySetting.Value, apiURLSetting.Value, options, req.Messages)
+	result, err := services.CallModel(c.Request.Context(), creds.Provider, creds.APIKey, creds.APIURL, options, req.Messages)
+	finishTestRun(run, result, err, start)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeProviderError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"response": response})
+	response := gin.H{
+		"response": result.Content,
+		"metrics":  result.ChatMetrics,
+		"run_id":   run.ID,
+	}
+	if len(req.Assertions) > 0 {
+		response["assertions"] = h.assertionService.Evaluate(c.Request.Context(), req.Assertions, req.Messages, result.Content, newJudge(creds.Provider))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// maxTestTargets 单次对比测试最多调用的模型数
+const maxTestTargets = 8
+
+// testTarget 对比测试中的一个模型及其参数，未指定的参数沿用请求中的值
+type testTarget struct {
+	Label       string   `json:"label"`
+	Provider    string   `json:"provider"`
+	Model       string   `json:"model"`
+	Temperature *float64 `json:"temperature"`
+	TopP        *float64 `json:"top_p"`
+	MaxTokens   int      `json:"max_tokens"`
+}
+
+// testTargetResult 对比测试中单个模型的结果，Target 为其在请求 targets 中的下标
+type testTargetResult struct {
+	Target     int                       `json:"target"`
+	Label      string                    `json:"label"`
+	Provider   string                    `json:"provider"`
+	Model      string                    `json:"model"`
+	Response   string                    `json:"response"`
+	Metrics    *services.ChatMetrics     `json:"metrics,omitempty"`
+	RunID      string                    `json:"run_id"`
+	Error      string                    `json:"error,omitempty"`
+	ErrorType  string                    `json:"error_type,omitempty"`
+	Assertions *services.AssertionReport `json:"assertions,omitempty"`
+}
+
+// testTargets 将同一组消息并行发送给多个模型
+// 非流式时返回按 targets 顺序排列的结果；流式时各事件的数据都带有 target 下标，单个模型失败不影响其他模型
+func (h *PromptHandler) testTargets(c *gin.Context, targets []testTarget, defaults services.ChatOptions, promptID string, messages []services.OpenAIMessage, stream bool, assertions models.Assertions) {
+	if len(targets) > maxTestTargets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d targets are allowed", maxTestTargets)})
+		return
+	}
+
+	results := make([]testTargetResult, len(targets))
+	credentials := make([]*providerCredentials, len(targets))
+	runs := make([]*models.TestRun, len(targets))
+	optionList := make([]services.ChatOptions, len(targets))
+	for i, target := range targets {
+		creds, err := lookupProviderCredentials(target.Provider, target.Model)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("target %d: %v", i+1, err)})
+			return
+		}
+		options := defaults
+		options.Model = creds.Model
+		if target.Temperature != nil {
+			options.Temperature = target.Temperature
+		}
+		if target.TopP != nil {
+			options.TopP = target.TopP
+		}
+		if target.MaxTokens > 0 {
+			options.MaxTokens = target.MaxTokens
+		}
+		label := target.Label
+		if label == "" {
+			label = string(creds.Provider) + "/" + creds.Model
+		}
+
+		credentials[i] = creds
+		optionList[i] = options
+		runs[i] = newTestRun(c, runKindTest, promptID, creds, options, messages, stream)
+		results[i] = testTargetResult{Target: i, Label: label, Provider: string(creds.Provider), Model: creds.Model}
+	}
+
+	// 多个模型的事件写入同一个响应，需要串行化
+	var send func(event string, data any)
+	if stream {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("Transfer-Encoding", "chunked")
+
+		var mu sync.Mutex
+		send = func(event string, data any) {
+			jsonData, _ := json.Marshal(data)
+			mu.Lock()
+			defer mu.Unlock()
+			c.SSEvent(event, string(jsonData))
+			c.Writer.Flush()
+		}
+	}
+
+	ctx := c.Request.Context()
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.runTestTarget(ctx, &results[i], credentials[i], optionList[i], runs[i], messages, assertions, send)
+		}(i)
+	}
+	wg.Wait()
+
+	if !stream && ctx.Err() == nil {
+		c.JSON(http.StatusOK, gin.H{"results": results})
+	}
+}
+
+// runTestTarget 调用单个对比模型并保存运行记录，send 不为空时以流式事件输出
+func (h *PromptHandler) runTestTarget(ctx context.Context, out *testTargetResult, creds *providerCredentials, options services.ChatOptions, run *models.TestRun, messages []services.OpenAIMessage, assertions models.Assertions, send func(event string, data any)) {
+	start := time.Now()
+	var result *services.ChatResult
+	var err error
+	if send != nil {
+		result, err = services.CallModelStream(ctx, creds.Provider, creds.APIKey, creds.APIURL, options, messages, func(text string) error {
+			send("message", gin.H{"target": out.Target, "text": text})
+			return nil
+		})
+	} else {
+		result, err = services.CallModel(ctx, creds.Provider, creds.APIKey, creds.APIURL, options, messages)
+	}
+	finishTestRun(run, result, err, start)
+	out.RunID = run.ID
+
+	if result != nil {
+		out.Response = result.Content
+		out.Metrics = &result.ChatMetrics
+	}
+	if err != nil {
+		out.Error = err.Error()
+		_, out.ErrorType = providerErrorStatus(err)
+		if send != nil && !errors.Is(err, context.Canceled) {
+			send("error", gin.H{"target": out.Target, "error": out.Error, "error_type": out.ErrorType})
+		}
+		return
+	}
+	if send != nil {
+		send("metrics", gin.H{"target": out.Target, "metrics": result.ChatMetrics, "run_id": run.ID})
+	}
+
+	if len(assertions) > 0 {
+		out.Assertions = h.assertionService.Evaluate(ctx, assertions, messages, result.Content, newJudge(creds.Provider))
+		if send != nil {
+			send("assertions", gin.H{"target": out.Target, "assertions": out.Assertions})
+		}
+	}
+}
+
+// renderTestMessages 使用提示词版本的变量定义（如有）渲染测试消息，失败时直接写入错误响应
+func (h *PromptHandler) renderTestMessages(c *gin.Context, promptID string, messages []services.OpenAIMessage, variables map[string]any) ([]services.OpenAIMessage, bool) {
+	var declared models.PromptVariables
+	if promptID != "" {
+		var prompt models.Prompt
+		if err := database.DB.First(&prompt, "id = ?", promptID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+				return nil, false
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+			return nil, false
+		}
+		declared = prompt.Variables
+	}
+
+	var allContent strings.Builder
+	for _, msg := range messages {
+		allContent.WriteString(msg.Content)
+		allContent.WriteString("\n")
+	}
+	schema := h.templateService.DetectSchema(allContent.String(), declared)
+
+	// 先整体校验一次，避免同一变量在多条消息中重复报错
+	if _, err := h.templateService.Render(allContent.String(), schema, variables); err != nil {
+		writeRenderError(c, err)
+		return nil, false
+	}
+
+	rendered := make([]services.OpenAIMessage, len(messages))
+	for i, msg := range messages {
+		content, _ := h.templateService.Render(msg.Content, schema, variables)
+		rendered[i] = services.OpenAIMessage{Role: msg.Role, Content: content}
+	}
+	return rendered, true
+}
+
+// writeRenderError 输出模板变量校验错误
+func writeRenderError(c *gin.Context, err error) {
+	var renderErr *services.RenderError
+	if errors.As(err, &renderErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template variables", "details": renderErr.Details})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// providerNamePattern 服务商名称同时用于设置项前缀和请求中的 provider 参数
+var providerNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+type ProviderHandler struct{}
+
+func NewProviderHandler() *ProviderHandler {
+	return &ProviderHandler{}
+}
+
+// providerRequest 创建和更新自定义服务商的请求体，api_key 写入设置项而不保存在服务商表中
+type providerRequest struct {
+	Name         string            `json:"name"`
+	DisplayName  *string           `json:"display_name"`
+	BaseURL      string            `json:"base_url"`
+	AuthScheme   string            `json:"auth_scheme"`
+	AuthHeader   *string           `json:"auth_header"`
+	DefaultModel *string           `json:"default_model"`
+	Models       []string          `json:"models"`
+	ExtraHeaders map[string]string `json:"extra_headers"`
+	StreamUsage  *bool             `json:"stream_usage"`
+	APIKey       *string           `json:"api_key"`
+}
+
+// providerItem 返回给前端的服务商信息，不包含 API Key
+type providerItem struct {
+	models.CustomProvider
+	HasAPIKey bool `json:"has_api_key"`
+}
+
+// GetProviders 获取内置服务商名称和所有自定义服务商
+func (h *ProviderHandler) GetProviders(c *gin.Context) {
+	var customProviders []models.CustomProvider
+	if err := database.DB.Order("name ASC").Find(&customProviders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch providers"})
+		return
+	}
+
+	items := make([]providerItem, 0, len(customProviders))
+	for _, p := range customProviders {
+		items = append(items, newProviderItem(p))
+	}
+
+	builtin := make([]string, 0)
+	for _, t := range services.GetSupportedProviders() {
+		if services.IsBuiltinProvider(t) {
+			builtin = append(builtin, string(t))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    items,
+		"total":   len(items),
+		"builtin": builtin,
+	})
+}
+
+// CreateProvider 创建自定义服务商并立即注册
+func (h *ProviderHandler) CreateProvider(c *gin.Context) {
+	var req providerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !providerNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must start with a lowercase letter and contain only lowercase letters, digits, '_' or '-' (2-50 characters)"})
+		return
+	}
+	if services.IsBuiltinProvider(services.ProviderType(req.Name)) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is a built-in provider", req.Name)})
+		return
+	}
+
+	provider := models.CustomProvider{
+		Name:       req.Name,
+		AuthScheme: services.AuthSchemeBearer,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := applyProviderRequest(&provider, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&provider).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "provider name already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create provider"})
+		return
+	}
+	if req.APIKey != nil {
+		if err := saveSetting(tx, services.GetProviderSettingsKey(services.ProviderType(provider.Name)), *req.APIKey); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save API key"})
+			return
+		}
+	}
+	tx.Commit()
+
+	registerCustomProvider(provider)
+	c.JSON(http.StatusCreated, newProviderItem(provider))
+}
+
+// UpdateProvider 更新自定义服务商，名称不可修改
+func (h *ProviderHandler) UpdateProvider(c *gin.Context) {
+	var provider models.CustomProvider
+	if err := database.DB.First(&provider, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch provider"})
+		return
+	}
+
+	var req providerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != "" && req.Name != provider.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider name cannot be changed"})
+		return
+	}
+	if err := applyProviderRequest(&provider, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider.UpdatedAt = time.Now()
+
+	tx := database.DB.Begin()
+	if err := tx.Save(&provider).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update provider"})
+		return
+	}
+	if req.APIKey != nil {
+		if err := saveSetting(tx, services.GetProviderSettingsKey(services.ProviderType(provider.Name)), *req.APIKey); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save API key"})
+			return
+		}
+	}
+	tx.Commit()
+
+	registerCustomProvider(provider)
+	c.JSON(http.StatusOK, newProviderItem(provider))
+}
+
+// DeleteProvider 删除自定义服务商及其设置项
+func (h *ProviderHandler) DeleteProvider(c *gin.Context) {
+	var provider models.CustomProvider
+	if err := database.DB.First(&provider, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch provider"})
+		return
+	}
+
+	providerType := services.ProviderType(provider.Name)
+	keys := []string{
+		services.GetProviderSettingsKey(providerType),
+		services.GetProviderURLKey(providerType),
+		services.GetProviderModelKey(providerType),
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where(map[string]any{"key": keys}).Delete(&models.Setting{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete provider settings"})
+		return
+	}
+	if err := tx.Delete(&provider).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete provider"})
+		return
+	}
+	tx.Commit()
+
+	services.UnregisterProvider(providerType)
+	c.JSON(http.StatusOK, gin.H{"message": "Provider deleted successfully"})
+}
+
+// LoadCustomProviders 启动时注册数据库中的所有自定义服务商
+func LoadCustomProviders() error {
+	var customProviders []models.CustomProvider
+	if err := database.DB.Find(&customProviders).Error; err != nil {
+		return err
+	}
+	for _, p := range customProviders {
+		if services.IsBuiltinProvider(services.ProviderType(p.Name)) {
+			slog.Warn("Skipping custom provider: name conflicts with a built-in provider", "provider", p.Name)
+			continue
+		}
+		registerCustomProvider(p)
+	}
+	return nil
+}
+
+func registerCustomProvider(p models.CustomProvider) {
+	services.RegisterProvider(services.ProviderType(p.Name), services.NewOpenAICompatibleProvider(services.OpenAICompatibleConfig{
+		Name:         p.Name,
+		BaseURL:      p.BaseURL,
+		AuthScheme:   p.AuthScheme,
+		AuthHeader:   p.AuthHeader,
+		DefaultModel: p.DefaultModel,
+		Models:       p.Models,
+		ExtraHeaders: p.ExtraHeaders,
+		StreamUsage:  p.StreamUsage,
+	}))
+}
+
+// applyProviderRequest 校验请求并写入服务商字段，未传入的字段保持不变
+func applyProviderRequest(p *models.CustomProvider, req *providerRequest) error {
+	if req.DisplayName != nil {
+		p.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.BaseURL != "" {
+		p.BaseURL = strings.TrimSpace(req.BaseURL)
+	}
+	if req.AuthScheme != "" {
+		p.AuthScheme = req.AuthScheme
+	}
+	if req.AuthHeader != nil {
+		p.AuthHeader = strings.TrimSpace(*req.AuthHeader)
+	}
+	if req.DefaultModel != nil {
+		p.DefaultModel = strings.TrimSpace(*req.DefaultModel)
+	}
+	if req.Models != nil {
+		p.Models = models.StringList(req.Models)
+	}
+	if req.ExtraHeaders != nil {
+		p.ExtraHeaders = models.StringMap(req.ExtraHeaders)
+	}
+	if req.StreamUsage != nil {
+		p.StreamUsage = *req.StreamUsage
+	}
+	if p.Models == nil {
+		p.Models = models.StringList{}
+	}
+	if p.ExtraHeaders == nil {
+		p.ExtraHeaders = models.StringMap{}
+	}
+
+	parsed, err := url.Parse(p.BaseURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("base_url must be an http or https URL")
+	}
+	switch p.AuthScheme {
+	case services.AuthSchemeBearer, services.AuthSchemeNone:
+	case services.AuthSchemeHeader:
+		if p.AuthHeader == "" {
+			return fmt.Errorf("auth_header is required when auth_scheme is header")
+		}
+	default:
+		return fmt.Errorf("auth_scheme must be one of bearer, header, none")
+	}
+	if p.DefaultModel == "" && len(p.Models) > 0 {
+		p.DefaultModel = p.Models[0]
+	}
+	if p.DefaultModel == "" {
+		return fmt.Errorf("default_model or models is required")
+	}
+	for key := range p.ExtraHeaders {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("extra_headers contains an empty header name")
+		}
+	}
+	return nil
+}
+
+func newProviderItem(p models.CustomProvider) providerItem {
+	var apiKey models.Setting
+	database.DB.Where(map[string]any{"key": services.GetProviderSettingsKey(services.ProviderType(p.Name))}).First(&apiKey)
+	return providerItem{CustomProvider: p, HasAPIKey: apiKey.Value != ""}
+}
+
+// saveSetting 写入或更新单个设置项
+func saveSetting(tx *gorm.DB, key, value string) error {
+	var setting models.Setting
+	if err := tx.Where(map[string]any{"key": key}).First(&setting).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		return tx.Create(&models.Setting{Key: key, Value: value}).Error
+	}
+	setting.Value = value
+	return tx.Save(&setting).Error
+}
+
+// providerCredentials 调用模型所需的服务商、API Key、地址和模型
+type providerCredentials struct {
+	Provider services.ProviderType
+	APIKey   string
+	APIURL   string
+	Model    string
+}
+
+// resolveProviderCredentials 从设置项读取服务商的调用参数，模型优先级为请求、设置、服务商默认值
+// 服务商不存在或缺少 API Key 时直接写入错误响应
+func resolveProviderCredentials(c *gin.Context, providerName, model string) (*providerCredentials, bool) {
+	creds, err := lookupProviderCredentials(providerName, model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return creds, true
+}
+
+// lookupProviderCredentials 同 resolveProviderCredentials，出错时返回错误而不写入响应
+func lookupProviderCredentials(providerName, model string) (*providerCredentials, error) {
+	providerType := services.ProviderType(providerName)
+	if providerType == "" {
+		providerType = services.ProviderAliyun
+	}
+
+	provider, err := services.GetProvider(providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiKeySetting models.Setting
+	database.DB.Where(map[string]any{"key": services.GetProviderSettingsKey(providerType)}).First(&apiKeySetting)
+
+	var apiURLSetting models.Setting
+	database.DB.Where(map[string]any{"key": services.GetProviderURLKey(providerType)}).First(&apiURLSetting)
+
+	if apiKeySetting.Value == "" && services.ProviderRequiresAPIKey(provider) {
+		return nil, fmt.Errorf("%s API Key not configured", providerType)
+	}
+
+	if model == "" {
+		var modelSetting models.Setting
+		database.DB.Where(map[string]any{"key": services.GetProviderModelKey(providerType)}).First(&modelSetting)
+		model = modelSetting.Value
+	}
+	if model == "" {
+		model = provider.GetDefaultModel()
+	}
+
+	return &providerCredentials{
+		Provider: providerType,
+		APIKey:   apiKeySetting.Value,
+		APIURL:   apiURLSetting.Value,
+		Model:    model,
+	}, nil
+}
+
+// newJudge 返回 llm_judge 断言使用的评审调用，断言未指定服务商和模型时使用被测模型的服务商及其默认模型
+func newJudge(defaultProvider services.ProviderType) services.JudgeFunc {
+	return func(ctx context.Context, assertion models.Assertion, messages []services.OpenAIMessage) (*services.ChatResult, error) {
+		providerName := assertion.Provider
+		if providerName == "" {
+			providerName = string(defaultProvider)
+		}
+		creds, err := lookupProviderCredentials(providerName, assertion.Model)
+		if err != nil {
+			return nil, err
+		}
+		temperature := 0.0
+		options := services.ChatOptions{Model: creds.Model, Temperature: &temperature}
+		return services.CallModel(ctx, creds.Provider, creds.APIKey, creds.APIURL, options, messages)
+	}
+}
+
+// providerErrorTypes 模型调用错误分类对应的响应状态码和 error_type
+var providerErrorTypes = []struct {
+	kind      error
+	status    int
+	errorType string
+}{
+	{services.ErrProviderAuth, http.StatusBadGateway, "auth"},
+	{services.ErrProviderRateLimited, http.StatusTooManyRequests, "rate_limit"},
+	{services.ErrProviderBadRequest, http.StatusBadRequest, "bad_request"},
+	{services.ErrProviderTimeout, http.StatusGatewayTimeout, "timeout"},
+	{services.ErrProviderUpstream, http.StatusBadGateway, "upstream"},
+}
+
+// writeProviderError 按错误分类写入模型调用失败的响应；上游鉴权失败返回 502，避免前端误判为登录失效
+func writeProviderError(c *gin.Context, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	var providerErr *services.ProviderError
+	if errors.As(err, &providerErr) && providerErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(providerErr.RetryAfter.Seconds()))))
+	}
+	status, errorType := providerErrorStatus(err)
+	if errorType == "" {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error(), "error_type": errorType})
+}
+
+// providerErrorStatus 返回模型调用错误对应的响应状态码和 error_type，未分类的错误 error_type 为空
+func providerErrorStatus(err error) (int, string) {
+	for _, t := range providerErrorTypes {
+		if errors.Is(err, t.kind) {
+			return t.status, t.errorType
+		}
+	}
+	return http.StatusInternalServerError, ""
+}
+
+// writeProviderStreamError 流式调用失败时发送 error 事件，浏览器已断开时不再写入
+func writeProviderStreamError(c *gin.Context, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	c.SSEvent("error", err.Error())
+}
+
+// writeStreamMetrics 流式调用结束后发送一条带用量、耗时、费用和运行记录 ID 的 metrics 事件
+func writeStreamMetrics(c *gin.Context, result *services.ChatResult, runID string) {
+	data, _ := json.Marshal(gin.H{"metrics": result.ChatMetrics, "run_id": runID})
+	c.SSEvent("metrics", string(data))
+	c.Writer.Flush()
+}
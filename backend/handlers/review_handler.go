@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/middleware"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 提示词版本的审批状态，空字符串表示创建时项目无需审批，与 approved 一样可以发布
+const (
+	reviewStatusDraft    = "draft"
+	reviewStatusInReview = "in_review"
+	reviewStatusApproved = "approved"
+	reviewStatusRejected = "rejected"
+)
+
+// 审批记录的操作类型
+const (
+	reviewActionSubmit  = "submit"
+	reviewActionComment = "comment"
+	reviewActionApprove = "approve"
+	reviewActionReject  = "reject"
+)
+
+// servableReviewStatuses SDK 可以获取的审批状态
+var servableReviewStatuses = []string{"", reviewStatusApproved}
+
+// errApprovedVersionLocked 需要审批的项目中，已发布的版本不能原地修改内容
+var errApprovedVersionLocked = errors.New("approved versions cannot be edited in place, create a new version instead")
+
+type ReviewHandler struct {
+	diffService *services.DiffService
+}
+
+func NewReviewHandler() *ReviewHandler {
+	return &ReviewHandler{
+		diffService: services.NewDiffService(),
+	}
+}
+
+// reviewSummary 提议版本的审批概况
+type reviewSummary struct {
+	Prompt            *models.Prompt        `json:"prompt"`
+	Base              *versionSummary       `json:"base"`
+	Diff              *services.DiffResult  `json:"diff"`
+	RequiredApprovals int                   `json:"required_approvals"`
+	Approvals         int                   `json:"approvals"`
+	Approvers         []string              `json:"approvers"`
+	Reviews           []models.PromptReview `json:"reviews"`
+}
+
+// reviewResponse 新增审批记录后返回的记录及版本最新的审批状态
+type reviewResponse struct {
+	models.PromptReview
+	ReviewStatus string `json:"review_status"`
+	Approvals    int    `json:"approvals"`
+}
+
+// GetProjectReviews 获取项目中待审批（或指定审批状态）的提示词版本
+func (h *ReviewHandler) GetProjectReviews(c *gin.Context) {
+	status := c.DefaultQuery("status", reviewStatusInReview)
+	switch status {
+	case reviewStatusDraft, reviewStatusInReview, reviewStatusApproved, reviewStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status, expected draft, in_review, approved or rejected"})
+		return
+	}
+
+	page, pageSize, offset := parsePagination(c)
+	query := database.DB.Model(&models.Prompt{}).Where("project_id = ? AND review_status = ?", c.Param("id"), status)
+	if name := c.Query("name"); name != "" {
+		query = query.Where("name = ?", name)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count reviews"})
+		return
+	}
+	var prompts []models.Prompt
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&prompts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      prompts,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetPromptReview 获取提议版本的审批概况，包括与基准版本的差异和全部审批记录
+func (h *ReviewHandler) GetPromptReview(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	summary := reviewSummary{Prompt: prompt, Approvers: []string{}}
+	if prompt.BaseID != "" {
+		var base models.Prompt
+		if err := database.DB.First(&base, "id = ?", prompt.BaseID).Error; err == nil {
+			summary.Base = &versionSummary{ID: base.ID, Version: base.Version, CreatedAt: base.CreatedAt}
+			diff := h.diffService.CompareTexts(base.Content, prompt.Content)
+			summary.Diff = &diff
+		}
+	}
+	if summary.Diff == nil {
+		diff := h.diffService.CompareTexts("", prompt.Content)
+		summary.Diff = &diff
+	}
+
+	var project models.Project
+	if err := database.DB.Select("required_approvals").First(&project, "id = ?", prompt.ProjectID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+	summary.RequiredApprovals = project.RequiredApprovals
+
+	if err := database.DB.Where("prompt_id = ?", prompt.ID).Order("created_at ASC").Find(&summary.Reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+	summary.Approvers = currentApprovers(summary.Reviews)
+	summary.Approvals = len(summary.Approvers)
+	c.JSON(http.StatusOK, summary)
+}
+
+// SubmitPromptReview 将草稿或被驳回的版本提交审批，之前的审批意见不再计入
+func (h *ReviewHandler) SubmitPromptReview(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if prompt.ReviewStatus != reviewStatusDraft && prompt.ReviewStatus != reviewStatusRejected {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft or rejected versions can be submitted for review"})
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review := models.PromptReview{
+		PromptID:  prompt.ID,
+		Reviewer:  requestActor(c, ""),
+		Action:    reviewActionSubmit,
+		Comment:   req.Comment,
+		CreatedAt: time.Now(),
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return tx.Model(prompt).Update("review_status", reviewStatusInReview).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit review"})
+		return
+	}
+	c.JSON(http.StatusOK, reviewResponse{PromptReview: review, ReviewStatus: reviewStatusInReview})
+}
+
+// CreatePromptReview 评论、批准或驳回审批中的版本，批准人数达到项目要求时版本变为 approved
+// 批准和驳回需要已认证的身份，且不能审批自己提交的版本
+func (h *ReviewHandler) CreatePromptReview(c *gin.Context) {
+	prompt, ok := loadPromptVersion(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Action  string `json:"action" binding:"required"`
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch req.Action {
+	case reviewActionComment:
+		if req.Comment == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "comment is required"})
+			return
+		}
+		if prompt.ReviewStatus == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Prompt version is not under review"})
+			return
+		}
+	case reviewActionApprove, reviewActionReject:
+		if prompt.ReviewStatus != reviewStatusInReview {
+			c.JSON(http.StatusConflict, gin.H{"error": "Prompt version is not in review"})
+			return
+		}
+		reviewer := middleware.CurrentActor(c)
+		if reviewer == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Approving or rejecting requires an authenticated reviewer"})
+			return
+		}
+		authors, err := proposalAuthors(prompt.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt authors"})
+			return
+		}
+		if authors[reviewer] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Authors cannot review their own changes"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action, expected comment, approve or reject"})
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.Select("required_approvals").First(&project, "id = ?", prompt.ProjectID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+
+	review := models.PromptReview{
+		PromptID:  prompt.ID,
+		Reviewer:  requestActor(c, ""),
+		Action:    req.Action,
+		Comment:   req.Comment,
+		CreatedAt: time.Now(),
+	}
+	resp := reviewResponse{ReviewStatus: prompt.ReviewStatus}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+
+		var reviews []models.PromptReview
+		if err := tx.Where("prompt_id = ?", prompt.ID).Order("created_at ASC").Find(&reviews).Error; err != nil {
+			return err
+		}
+		resp.Approvals = len(currentApprovers(reviews))
+
+		switch {
+		case req.Action == reviewActionReject:
+			resp.ReviewStatus = reviewStatusRejected
+		case req.Action == reviewActionApprove && resp.Approvals >= max(project.RequiredApprovals, 1):
+			resp.ReviewStatus = reviewStatusApproved
+		default:
+			return nil
+		}
+		return tx.Model(prompt).Update("review_status", resp.ReviewStatus).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	resp.PromptReview = review
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateReviewSettings 设置项目发布新版本所需的审批人数，0 表示无需审批
+func (h *ReviewHandler) UpdateReviewSettings(c *gin.Context) {
+	var req struct {
+		RequiredApprovals *int `json:"required_approvals" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *req.RequiredApprovals < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "required_approvals must not be negative"})
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.First(&project, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+
+	if err := database.DB.Model(&project).Updates(map[string]any{
+		"required_approvals": *req.RequiredApprovals,
+		"updated_at":         time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project_id": project.ID, "required_approvals": *req.RequiredApprovals})
+}
+
+// currentApprovers 最近一次提交审批之后批准过且未再驳回的审批人，reviews 需按时间升序
+func currentApprovers(reviews []models.PromptReview) []string {
+	approved := map[string]bool{}
+	var order []string
+	for _, review := range reviews {
+		switch review.Action {
+		case reviewActionSubmit:
+			approved = map[string]bool{}
+			order = nil
+		case reviewActionApprove:
+			if !approved[review.Reviewer] {
+				order = append(order, review.Reviewer)
+			}
+			approved[review.Reviewer] = true
+		case reviewActionReject:
+			approved[review.Reviewer] = false
+		}
+	}
+
+	approvers := []string{}
+	for _, reviewer := range order {
+		if approved[reviewer] {
+			approvers = append(approvers, reviewer)
+		}
+	}
+	return approvers
+}
+
+// proposalAuthors 创建、修改或提交过该版本的操作人
+func proposalAuthors(promptID string) (map[string]bool, error) {
+	var authors []string
+	if err := database.DB.Model(&models.PromptHistory{}).Where("prompt_id = ?", promptID).Pluck("author", &authors).Error; err != nil {
+		return nil, err
+	}
+	var submitters []string
+	if err := database.DB.Model(&models.PromptReview{}).Where("prompt_id = ? AND action = ?", promptID, reviewActionSubmit).Pluck("reviewer", &submitters).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(authors)+len(submitters))
+	for _, author := range append(authors, submitters...) {
+		result[author] = true
+	}
+	return result, nil
+}
+
+// initialReviewStatus 新版本的初始审批状态：项目需要审批时为 draft，submit 为 true 时直接提交审批
+func initialReviewStatus(tx *gorm.DB, projectID string, submit bool) (string, error) {
+	var project models.Project
+	if err := tx.Select("required_approvals").First(&project, "id = ?", projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	if project.RequiredApprovals == 0 {
+		return "", nil
+	}
+	if submit {
+		return reviewStatusInReview, nil
+	}
+	return reviewStatusDraft, nil
+}
+
+// editedReviewStatus 原地修改版本内容后的审批状态：审批中的版本退回草稿，需要重新提交；
+// 项目需要审批时，已发布的版本不能原地修改
+func editedReviewStatus(tx *gorm.DB, prompt *models.Prompt) (string, error) {
+	switch prompt.ReviewStatus {
+	case reviewStatusDraft, reviewStatusInReview, reviewStatusRejected:
+		return reviewStatusDraft, nil
+	}
+	status, err := initialReviewStatus(tx, prompt.ProjectID, false)
+	if err != nil {
+		return "", err
+	}
+	if status != "" {
+		return "", errApprovedVersionLocked
+	}
+	return prompt.ReviewStatus, nil
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 运行记录的类型
+const (
+	runKindTest     = "test"
+	runKindOptimize = "optimize"
+)
+
+// 运行记录的状态
+const (
+	runStatusSuccess  = "success"
+	runStatusError    = "error"
+	runStatusCanceled = "canceled"
+)
+
+// runCleanupInterval 清理过期运行记录的间隔
+const runCleanupInterval = time.Hour
+
+type RunHandler struct{}
+
+func NewRunHandler() *RunHandler {
+	return &RunHandler{}
+}
+
+// GetRuns 分页查询运行记录
+func (h *RunHandler) GetRuns(c *gin.Context) {
+	query := h.runQuery(c)
+	if projectID := c.Query("project_id"); projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
+	if promptID := c.Query("prompt_id"); promptID != "" {
+		query = query.Where("prompt_id = ?", promptID)
+	}
+	h.writeRunPage(c, query)
+}
+
+// GetPromptRuns 查询某个提示词版本的运行记录
+func (h *RunHandler) GetPromptRuns(c *gin.Context) {
+	h.writeRunPage(c, h.runQuery(c).Where("prompt_id = ?", c.Param("id")))
+}
+
+// GetRun 获取单条运行记录
+func (h *RunHandler) GetRun(c *gin.Context) {
+	run, ok := h.findRun(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// RerunRun 使用相同的服务商、模型、参数和消息重新调用，结果保存为一条新的运行记录
+// 重新运行始终为非流式调用，API Key 和地址使用当前设置
+func (h *RunHandler) RerunRun(c *gin.Context) {
+	source, ok := h.findRun(c)
+	if !ok {
+		return
+	}
+
+	creds, ok := resolveProviderCredentials(c, source.Provider, source.Model)
+	if !ok {
+		return
+	}
+
+	options := services.ChatOptions{
+		Model:       creds.Model,
+		Temperature: source.Temperature,
+		TopP:        source.TopP,
+		MaxTokens:   source.MaxTokens,
+	}
+	messages := make([]services.OpenAIMessage, len(source.Messages))
+	for i, message := range source.Messages {
+		messages[i] = services.OpenAIMessage{Role: message.Role, Content: message.Content}
+	}
+
+	run := newTestRun(c, source.Kind, source.PromptID, creds, options, messages, false)
+	run.RerunOf = source.ID
+
+	start := time.Now()
+	result, err := services.CallModel(c.Request.Context(), creds.Provider, creds.APIKey, creds.APIURL, options, messages)
+	finishTestRun(run, result, err, start)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// DeleteRun 删除单条运行记录
+func (h *RunHandler) DeleteRun(c *gin.Context) {
+	result := database.DB.Where("id = ?", c.Param("id")).Delete(&models.TestRun{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete run"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Run not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Run deleted successfully"})
+}
+
+// PurgeRuns 删除 before 之前的运行记录，before 支持 RFC3339 或 2006-01-02 格式
+func (h *RunHandler) PurgeRuns(c *gin.Context) {
+	before, err := parseRunTime(c.Query("before"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before must be an RFC3339 time or a date like 2006-01-02"})
+		return
+	}
+
+	deleted, err := purgeRunsBefore(before)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete runs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+func (h *RunHandler) findRun(c *gin.Context) (*models.TestRun, bool) {
+	var run models.TestRun
+	if err := database.DB.First(&run, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Run not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch run"})
+		return nil, false
+	}
+	return &run, true
+}
+
+// runQuery 构建运行记录查询，支持按类型、服务商、模型、状态、关键字和时间范围筛选
+func (h *RunHandler) runQuery(c *gin.Context) *gorm.DB {
+	query := database.DB.Model(&models.TestRun{})
+
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if provider := c.Query("provider"); provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	if model := c.Query("model"); model != "" {
+		query = query.Where("model = ?", model)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if keyword := c.Query("q"); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("messages LIKE ? OR response LIKE ? OR error LIKE ?", like, like, like)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("created_at >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("created_at <= ?", endDate)
+	}
+	return query
+}
+
+func (h *RunHandler) writeRunPage(c *gin.Context, query *gorm.DB) {
+	page, pageSize, offset := parsePagination(c)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count runs"})
+		return
+	}
+
+	var runs []models.TestRun
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      runs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// newTestRun 按调用参数创建运行记录，关联提示词版本时同时记录其所属项目
+func newTestRun(c *gin.Context, kind, promptID string, creds *providerCredentials, options services.ChatOptions, messages []services.OpenAIMessage, stream bool) *models.TestRun {
+	run := &models.TestRun{
+		Kind:        kind,
+		PromptID:    promptID,
+		Provider:    string(creds.Provider),
+		Model:       options.Model,
+		Temperature: options.Temperature,
+		TopP:        options.TopP,
+		MaxTokens:   options.MaxTokens,
+		Stream:      stream,
+		Messages:    make(models.ChatMessages, len(messages)),
+		Actor:       requestActor(c, ""),
+		CreatedAt:   time.Now(),
+	}
+	for i, message := range messages {
+		run.Messages[i] = models.ChatMessage{Role: message.Role, Content: message.Content}
+	}
+	if promptID != "" {
+		var prompt models.Prompt
+		if err := database.DB.Select("project_id").First(&prompt, "id = ?", promptID).Error; err == nil {
+			run.ProjectID = prompt.ProjectID
+		}
+	}
+	return run
+}
+
+// finishTestRun 写入调用结果并保存运行记录；流式调用失败时保留已收到的部分内容，保存失败只记录日志
+func finishTestRun(run *models.TestRun, result *services.ChatResult, err error, start time.Time) {
+	run.Status = runStatusSuccess
+	run.LatencyMs = time.Since(start).Milliseconds()
+	if result != nil {
+		run.Response = result.Content
+		run.ResponseModel = result.Model
+		run.FinishReason = result.FinishReason
+		run.PromptTokens = result.PromptTokens
+		run.CompletionTokens = result.CompletionTokens
+		run.TotalTokens = result.TotalTokens
+		run.TimeToFirstTokenMs = result.TimeToFirstTokenMs
+		run.LatencyMs = result.LatencyMs
+		run.Cost = result.Cost
+		run.Currency = result.Currency
+	}
+	if err != nil {
+		run.Status = runStatusError
+		if errors.Is(err, context.Canceled) {
+			run.Status = runStatusCanceled
+		}
+		run.Error = err.Error()
+		_, run.ErrorType = providerErrorStatus(err)
+	}
+
+	if err := database.DB.Create(run).Error; err != nil {
+		slog.Error("Failed to save test run", "prompt_id", run.PromptID, "error", err)
+	}
+}
+
+// purgeRunsBefore 删除指定时间之前的运行记录，返回删除的条数
+func purgeRunsBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("created_at < ?", before).Delete(&models.TestRun{})
+	return result.RowsAffected, result.Error
+}
+
+// StartRunCleanup 启动后台任务，按保留时长定期清理过期的运行记录，retention 为 0 时不清理
+func StartRunCleanup(retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	cleanup := func() {
+		deleted, err := purgeRunsBefore(time.Now().Add(-retention))
+		if err != nil {
+			slog.Error("Failed to clean up test runs", "error", err)
+			return
+		}
+		if deleted > 0 {
+			slog.Info("Cleaned up test runs", "deleted", deleted, "older_than", retention)
+		}
+	}
+
+	go func() {
+		cleanup()
+		ticker := time.NewTicker(runCleanupInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			cleanup()
+		}
+	}()
+}
+
+func parseRunTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
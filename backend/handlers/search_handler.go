@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 高亮片段的长度（字符数）
+const (
+	searchNameWidth    = 100
+	searchSnippetWidth = 160
+)
+
+// searchRow 全文搜索命中的提示词版本及其所属项目
+type searchRow struct {
+	PromptID    string
+	EntityID    string
+	ProjectID   string
+	ProjectName string
+	Name        string
+	Version     string
+	Status      string
+	Description string
+	Content     string
+	CreatedAt   time.Time
+	Score       float64
+}
+
+// searchHit 搜索结果，highlights 只包含命中关键词的字段
+type searchHit struct {
+	PromptID    string            `json:"prompt_id"`
+	EntityID    string            `json:"entity_id"`
+	ProjectID   string            `json:"project_id"`
+	ProjectName string            `json:"project_name"`
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Score       float64           `json:"score"`
+	Highlights  map[string]string `json:"highlights"`
+}
+
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+func NewSearchHandler() *SearchHandler {
+	return &SearchHandler{
+		searchService: services.NewSearchService(),
+	}
+}
+
+// Search 跨项目全文搜索提示词名称、描述和内容，按相关度排序分页返回
+// 多个关键词之间为“且”关系，可按项目和生命周期状态筛选，回收站中的记录不参与搜索
+func (h *SearchHandler) Search(c *gin.Context) {
+	terms := h.searchService.Terms(c.Query("q"))
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	query := database.DB.Table("prompts").
+		Joins("JOIN projects ON projects.id = prompts.project_id").
+		Where("prompts.deleted_at IS NULL AND projects.deleted_at IS NULL")
+	if projectID := c.Query("project_id"); projectID != "" {
+		query = query.Where("prompts.project_id = ?", projectID)
+	}
+	if status := c.Query("status"); status != "" {
+		statuses := strings.Split(status, ",")
+		for _, s := range statuses {
+			if _, ok := promptStatusTransitions[s]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status, expected draft, published, deprecated or archived"})
+				return
+			}
+		}
+		query = query.Where("prompts.status IN ?", statuses)
+	}
+	query, score, scoreArgs := database.MatchSearchTerms(query, terms)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search prompts"})
+		return
+	}
+
+	page, pageSize, offset := parsePagination(c)
+	var rows []searchRow
+	if err := query.Select("prompts.id AS prompt_id, prompts.entity_id, prompts.project_id, projects.name AS project_name, "+
+		"prompts.name, prompts.version, prompts.status, prompts.description, prompts.content, prompts.created_at, "+score+" AS score", scoreArgs...).
+		Order("score DESC").Order("prompts.created_at DESC").
+		Offset(offset).Limit(pageSize).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search prompts"})
+		return
+	}
+
+	hits := make([]searchHit, 0, len(rows))
+	for _, row := range rows {
+		hit := searchHit{
+			PromptID:    row.PromptID,
+			EntityID:    row.EntityID,
+			ProjectID:   row.ProjectID,
+			ProjectName: row.ProjectName,
+			Name:        row.Name,
+			Version:     row.Version,
+			Status:      row.Status,
+			CreatedAt:   row.CreatedAt,
+			Score:       row.Score,
+			Highlights:  map[string]string{},
+		}
+		for field, snippet := range map[string]string{
+			"name":        h.searchService.Highlight(row.Name, terms, searchNameWidth),
+			"description": h.searchService.Highlight(row.Description, terms, searchSnippetWidth),
+			"content":     h.searchService.Highlight(row.Content, terms, searchSnippetWidth),
+		} {
+			if snippet != "" {
+				hit.Highlights[field] = snippet
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      hits,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -2,11 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"prompt-manager/database"
 	"prompt-manager/models"
 	"prompt-manager/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -42,7 +42,7 @@ func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
 	for k, v := range input {
 		// Using Clauses to handle upsert if needed, or just manual check
 		var setting models.Setting
-		if err := tx.Where("`key` = ?", k).First(&setting).Error; err != nil {
+		if err := tx.Where(map[string]any{"key": k}).First(&setting).Error; err != nil {
 			// Create
 			setting = models.Setting{Key: k, Value: v}
 			if err := tx.Create(&setting).Error; err != nil {
@@ -77,42 +77,13 @@ func (h *SettingsHandler) OptimizePrompt(c *gin.Context) {
 		return
 	}
 
-	provider := services.ProviderType(req.Provider)
-	if provider == "" {
-		provider = services.ProviderAliyun
-	}
-
-	// Get provider-specific settings
-	apiKeyKey := services.GetProviderSettingsKey(provider)
-	apiURLKey := services.GetProviderURLKey(provider)
-	modelKey := services.GetProviderModelKey(provider)
-
-	var apiKeySetting models.Setting
-	database.DB.Where("`key` = ?", apiKeyKey).First(&apiKeySetting)
-
-	var apiURLSetting models.Setting
-	database.DB.Where("`key` = ?", apiURLKey).First(&apiURLSetting)
-
-	var modelSetting models.Setting
-	database.DB.Where("`key` = ?", modelKey).First(&modelSetting)
-
-	if apiKeySetting.Value == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s API Key not configured", provider)})
+	creds, ok := resolveProviderCredentials(c, req.Provider, "")
+	if !ok {
 		return
 	}
 
-	model := modelSetting.Value
-	if model == "" {
-		providerObj, err := services.GetProvider(provider)
-		if err == nil {
-			model = providerObj.GetDefaultModel()
-		} else {
-			model = "qwen-turbo"
-		}
-	}
-
 	options := services.ChatOptions{
-		Model:       model,
+		Model:       creds.Model,
 		Temperature: nil,
 		TopP:        nil,
 		MaxTokens:   0,
@@ -122,31 +93,42 @@ func (h *SettingsHandler) OptimizePrompt(c *gin.Context) {
 		{Role: "user", Content: req.Prompt},
 	}
 
+	run := newTestRun(c, runKindOptimize, "", creds, options, messages, req.Stream)
+	start := time.Now()
+
 	if req.Stream {
 		c.Header("Content-Type", "text/event-stream")
 		c.Header("Cache-Control", "no-cache")
 		c.Header("Connection", "keep-alive")
 		c.Header("Transfer-Encoding", "chunked")
 
-		err := services.CallModelStream(provider, apiKeySetting.Value, apiURLSetting.Value, options, messages, func(text string) error {
+		result, err := services.CallModelStream(c.Request.Context(), creds.Provider, creds.APIKey, creds.APIURL, options, messages, func(text string) error {
 			data := map[string]string{"text": text}
 			jsonData, _ := json.Marshal(data)
 			c.SSEvent("message", string(jsonData))
 			c.Writer.Flush()
 			return nil
 		})
+		finishTestRun(run, result, err, start)
 
 		if err != nil {
-			c.SSEvent("error", err.Error())
+			writeProviderStreamError(c, err)
+			return
 		}
+		writeStreamMetrics(c, result, run.ID)
 		return
 	}
 
-	optimized, err := services.CallModel(provider, apiKeySetting.Value, apiURLSetting.Value, options, messages)
+	result, err := services.CallModel(c.Request.Context(), creds.Provider, creds.APIKey, creds.APIURL, options, messages)
+	finishTestRun(run, result, err, start)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeProviderError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"optimized_prompt": optimized})
+	c.JSON(http.StatusOK, gin.H{
+		"optimized_prompt": result.Content,
+		"metrics":          result.ChatMetrics,
+		"run_id":           run.ID,
+	})
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"prompt-manager/database"
 	"prompt-manager/models"
@@ -16,19 +17,35 @@ func NewTagHandler() *TagHandler {
 	return &TagHandler{}
 }
 
-// GetTags 获取所有标签
+// tagListSpec 标签列表可排序、可选择的字段
+var tagListSpec = &listSpec{
+	table:       "tags",
+	sortable:    []string{"name", "created_at"},
+	defaultSort: "created_at",
+	fields:      map[string]string{"id": "id", "name": "name", "color": "color", "created_at": "created_at"},
+}
+
+// GetTags 获取标签列表，支持分页、排序和字段选择
 func (h *TagHandler) GetTags(c *gin.Context) {
-	var tags []models.Tag
+	q, ok := parseListQuery(c, tagListSpec)
+	if !ok {
+		return
+	}
+	
+	query := database.DB.Model(&models.Tag{})
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count tags"})
+		return
+	}
 	
-	if err := database.DB.Find(&tags).Error; err != nil {
+	var tags []models.Tag
+	if err := q.apply(query).Find(&tags).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
-		"data": tags,
-		"total": len(tags),
-	})
+	q.respond(c, tags, total)
 }
 
 // GetTag 获取单个标签
@@ -64,6 +81,16 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 		req.Color = "#3b82f6"
 	}
 	
+	// 同名标签在回收站中时直接恢复，保留它原有的提示词关联
+	var trashed models.Tag
+	if restored, err := restoreTrashedByName(&trashed, req.Name, req.Color); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore tag"})
+		return
+	} else if restored {
+		c.JSON(http.StatusCreated, trashed)
+		return
+	}
+	
 	tag := models.Tag{
 		Name:      req.Name,
 		Color:     req.Color,
@@ -71,6 +98,10 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 	}
 	
 	if err := database.DB.Create(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Tag name already exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
 		return
 	}
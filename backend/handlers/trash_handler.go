@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 回收站中的记录类型
+const (
+	trashTypeProject  = "project"
+	trashTypePrompt   = "prompt"
+	trashTypeTag      = "tag"
+	trashTypeCategory = "category"
+)
+
+// trashPurgeInterval 清理回收站中过期记录的间隔
+const trashPurgeInterval = time.Hour
+
+// trashItem 回收站中的一条记录
+type trashItem struct {
+	Type      string     `json:"type"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	ProjectID string     `json:"project_id,omitempty"`
+	Version   string     `json:"version,omitempty"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"` // 到期后由清理任务彻底删除，永久保留时为空
+}
+
+type TrashHandler struct {
+	retention time.Duration
+}
+
+func NewTrashHandler(retention time.Duration) *TrashHandler {
+	return &TrashHandler{retention: retention}
+}
+
+// GetTrash 获取回收站中的记录，可按类型、项目筛选，按删除时间倒序分页
+// 随项目一起删除的提示词版本不单独列出，恢复项目时一并恢复
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	kind := c.Query("type")
+	switch kind {
+	case "", trashTypeProject, trashTypePrompt, trashTypeTag, trashTypeCategory:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type, expected project, prompt, tag or category"})
+		return
+	}
+	projectID := c.Query("project_id")
+
+	items, err := listTrash(kind, projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	if h.retention > 0 {
+		for i := range items {
+			purgeAt := items[i].DeletedAt.Add(h.retention)
+			items[i].PurgeAt = &purgeAt
+		}
+	}
+
+	page, pageSize, offset := parsePagination(c)
+	total := len(items)
+	end := offset + pageSize
+	if offset > total {
+		offset = total
+	}
+	if end > total {
+		end = total
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":      items[offset:end],
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RestoreTrash 从回收站恢复记录：恢复项目时一并恢复随项目删除的提示词版本，
+// 恢复提示词版本时重新关联提示词实体，标签关联和历史记录随版本一起恢复
+func (h *TrashHandler) RestoreTrash(c *gin.Context) {
+	id := c.Param("id")
+
+	switch c.Param("type") {
+	case trashTypeProject:
+		var project models.Project
+		if !loadTrashed(c, &project, id, "Project") {
+			return
+		}
+		tx := database.DB.Begin()
+		if err := restoreProject(tx, &project); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore project"})
+			return
+		}
+		tx.Commit()
+		c.JSON(http.StatusOK, project)
+
+	case trashTypePrompt:
+		var prompt models.Prompt
+		if !loadTrashed(c, &prompt, id, "Prompt") {
+			return
+		}
+		var project models.Project
+		if err := database.DB.Select("id").First(&project, "id = ?", prompt.ProjectID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusConflict, gin.H{"error": "Project is in the trash, restore the project first"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+			return
+		}
+
+		tx := database.DB.Begin()
+		// 实体已不存在时（如早于回收站的数据）按名称重新关联
+		entity, err := ensurePromptEntity(tx, prompt.ProjectID, prompt.Name, prompt.Description, prompt.Category)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore prompt entity"})
+			return
+		}
+		if err := tx.Unscoped().Model(&prompt).Updates(map[string]any{"deleted_at": nil, "entity_id": entity.ID}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore prompt"})
+			return
+		}
+		tx.Commit()
+
+		if err := database.DB.Preload("Tags").First(&prompt, "id = ?", id).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompt"})
+			return
+		}
+		c.JSON(http.StatusOK, prompt)
+
+	case trashTypeTag:
+		var tag models.Tag
+		if !loadTrashed(c, &tag, id, "Tag") {
+			return
+		}
+		if err := database.DB.Unscoped().Model(&tag).Update("deleted_at", nil).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore tag"})
+			return
+		}
+		c.JSON(http.StatusOK, tag)
+
+	case trashTypeCategory:
+		var category models.Category
+		if !loadTrashed(c, &category, id, "Category") {
+			return
+		}
+		if err := database.DB.Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore category"})
+			return
+		}
+		c.JSON(http.StatusOK, category)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type, expected project, prompt, tag or category"})
+	}
+}
+
+// restoreProject 恢复回收站中的项目，以及随项目一起删除的提示词版本
+func restoreProject(tx *gorm.DB, project *models.Project) error {
+	if err := tx.Unscoped().Model(&models.Prompt{}).
+		Where("project_id = ? AND deleted_at >= ?", project.ID, project.DeletedAt.Time).
+		Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(project).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	project.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// PurgeTrash 立即彻底删除回收站中的一条记录
+func (h *TrashHandler) PurgeTrash(c *gin.Context) {
+	kind, id := c.Param("type"), c.Param("id")
+
+	var model any
+	switch kind {
+	case trashTypeProject:
+		model = &models.Project{}
+	case trashTypePrompt:
+		model = &models.Prompt{}
+	case trashTypeTag:
+		model = &models.Tag{}
+	case trashTypeCategory:
+		model = &models.Category{}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type, expected project, prompt, tag or category"})
+		return
+	}
+	var count int64
+	if err := database.DB.Unscoped().Model(model).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := purgeTrashItem(tx, kind, id); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge item"})
+		return
+	}
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Item purged successfully"})
+}
+
+// loadTrashed 加载回收站中的记录，不存在或未被删除时直接写入 404 响应
+func loadTrashed(c *gin.Context, dest any, id, name string) bool {
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(dest, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": name + " not found in trash"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return false
+	}
+	return true
+}
+
+// listTrash 查询回收站中的记录，kind 为空时返回全部类型
+func listTrash(kind, projectID string) ([]trashItem, error) {
+	items := []trashItem{}
+	trashed := database.DB.Unscoped().Where("deleted_at IS NOT NULL")
+
+	if kind == "" || kind == trashTypeProject {
+		var projects []models.Project
+		query := trashed.Session(&gorm.Session{})
+		if projectID != "" {
+			query = query.Where("id = ?", projectID)
+		}
+		if err := query.Find(&projects).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			items = append(items, trashItem{Type: trashTypeProject, ID: p.ID, Name: p.Name, DeletedAt: p.DeletedAt.Time})
+		}
+	}
+
+	if kind == "" || kind == trashTypePrompt {
+		var prompts []models.Prompt
+		query := trashed.Session(&gorm.Session{}).Select("id", "project_id", "name", "version", "deleted_at").
+			Where("project_id NOT IN (?)", database.DB.Unscoped().Model(&models.Project{}).Select("id").Where("deleted_at IS NOT NULL"))
+		if projectID != "" {
+			query = query.Where("project_id = ?", projectID)
+		}
+		if err := query.Find(&prompts).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range prompts {
+			items = append(items, trashItem{Type: trashTypePrompt, ID: p.ID, Name: p.Name, ProjectID: p.ProjectID, Version: p.Version, DeletedAt: p.DeletedAt.Time})
+		}
+	}
+
+	// 标签和分类不属于项目，按项目筛选时不返回
+	if projectID != "" {
+		return items, nil
+	}
+
+	if kind == "" || kind == trashTypeTag {
+		var tags []models.Tag
+		if err := trashed.Session(&gorm.Session{}).Find(&tags).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			items = append(items, trashItem{Type: trashTypeTag, ID: t.ID, Name: t.Name, DeletedAt: t.DeletedAt.Time})
+		}
+	}
+
+	if kind == "" || kind == trashTypeCategory {
+		var categories []models.Category
+		if err := trashed.Session(&gorm.Session{}).Find(&categories).Error; err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			items = append(items, trashItem{Type: trashTypeCategory, ID: category.ID, Name: category.Name, DeletedAt: category.DeletedAt.Time})
+		}
+	}
+	return items, nil
+}
+
+// purgeTrashItem 彻底删除一条记录及其关联数据
+func purgeTrashItem(tx *gorm.DB, kind, id string) error {
+	switch kind {
+	case trashTypeProject:
+		return purgeProject(tx, id)
+	case trashTypePrompt:
+		return purgePrompts(tx, []string{id})
+	case trashTypeTag:
+		for _, table := range []string{"prompt_tags", "project_tags", "prompt_entity_tags"} {
+			if err := deleteJoinRows(tx, table, "tag_id", id); err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&models.Tag{}, "id = ?", id).Error
+	case trashTypeCategory:
+		return tx.Unscoped().Delete(&models.Category{}, "id = ?", id).Error
+	}
+	return nil
+}
+
+// purgeTrashBefore 彻底删除在指定时间之前移入回收站的记录，返回删除的记录数
+func purgeTrashBefore(before time.Time) (int, error) {
+	targets := []struct {
+		kind  string
+		model any
+	}{
+		{trashTypeProject, &models.Project{}},
+		{trashTypePrompt, &models.Prompt{}},
+		{trashTypeTag, &models.Tag{}},
+		{trashTypeCategory, &models.Category{}},
+	}
+
+	purged := 0
+	for _, target := range targets {
+		var ids []string
+		if err := database.DB.Unscoped().Model(target.model).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		for _, id := range ids {
+			tx := database.DB.Begin()
+			if err := purgeTrashItem(tx, target.kind, id); err != nil {
+				tx.Rollback()
+				return purged, err
+			}
+			if err := tx.Commit().Error; err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// StartTrashPurge 启动后台任务，按保留时长定期彻底删除回收站中过期的记录，retention 为 0 时不清理
+func StartTrashPurge(retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	purge := func() {
+		purged, err := purgeTrashBefore(time.Now().Add(-retention))
+		if err != nil {
+			slog.Error("Failed to purge trash", "error", err)
+		}
+		if purged > 0 {
+			slog.Info("Purged trash items", "purged", purged, "older_than", retention)
+		}
+	}
+
+	go func() {
+		purge()
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UserHandler struct{}
+
+func NewUserHandler() *UserHandler {
+	return &UserHandler{}
+}
+
+// GetUsers 获取用户列表
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	var users []models.User
+	if err := database.DB.Order("created_at ASC").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  users,
+		"total": len(users),
+	})
+}
+
+// CreateUser 创建用户
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required,min=8"`
+		Role     string `json:"role" binding:"required,oneof=admin editor viewer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate username"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "username already exists"})
+		return
+	}
+
+	hash, err := services.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	user := models.User{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         req.Role,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser 更新用户角色、密码或禁用状态
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id := c.Param("id")
+
+	var req struct {
+		Password string `json:"password" binding:"omitempty,min=8"`
+		Role     string `json:"role" binding:"omitempty,oneof=admin editor viewer"`
+		Disabled *bool  `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	revokeSessions := false
+	if req.Password != "" {
+		hash, err := services.HashPassword(req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		user.PasswordHash = hash
+		revokeSessions = true
+	}
+	if req.Role != "" {
+		user.Role = req.Role
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+		revokeSessions = revokeSessions || user.Disabled
+	}
+	user.UpdatedAt = time.Now()
+
+	tx := database.DB.Begin()
+	if err := tx.Save(&user).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	// 修改密码或禁用后，已有会话全部失效
+	if revokeSessions {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Session{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser 删除用户及其会话和项目角色
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	if user := currentUser(c); user != nil && user.ID == id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot delete the current user"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("user_id = ?", id).Delete(&models.Session{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sessions"})
+		return
+	}
+	if err := tx.Where("user_id = ?", id).Delete(&models.ProjectMember{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project roles"})
+		return
+	}
+	if err := tx.Delete(&models.User{}, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// GetProjectMembers 获取项目成员及其角色
+func (h *UserHandler) GetProjectMembers(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var members []models.ProjectMember
+	if err := database.DB.Preload("User").Where("project_id = ?", projectID).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  members,
+		"total": len(members),
+	})
+}
+
+// SetProjectMember 设置用户在项目中的角色
+func (h *UserHandler) SetProjectMember(c *gin.Context) {
+	projectID := c.Param("id")
+	userID := c.Param("user_id")
+
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=admin editor viewer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	var projectCount int64
+	if err := database.DB.Model(&models.Project{}).Where("id = ?", projectID).Count(&projectCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		return
+	}
+	if projectCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	member := models.ProjectMember{ProjectID: projectID, UserID: userID}
+	if err := database.DB.Where(&member).Attrs(models.ProjectMember{CreatedAt: time.Now()}).FirstOrInit(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project member"})
+		return
+	}
+	member.Role = req.Role
+	if err := database.DB.Save(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save project member"})
+		return
+	}
+	member.User = user
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveProjectMember 移除用户的项目角色，之后按全局角色鉴权
+func (h *UserHandler) RemoveProjectMember(c *gin.Context) {
+	projectID := c.Param("id")
+	userID := c.Param("user_id")
+
+	if err := database.DB.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&models.ProjectMember{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove project member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project member removed successfully"})
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger 将 GORM 日志写入 slog：执行出错的 SQL 记为 error，超过慢查询阈值的记为 warn，
+// 其余 SQL 仅在 debug 级别记录。SQL 中的参数不会写入日志，避免泄露 API Key 等敏感配置；
+// 例外是 Scan 查询，GORM 内部先生成带参数值的 SQL 再交给日志，因此不要用 Scan 读写敏感数据
+type GormLogger struct {
+	slowThreshold time.Duration
+	mode          logger.LogLevel // 为 0 时按 slog 的日志级别决定，db.Debug() 等调用 LogMode 后以其为准
+}
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{slowThreshold: slowThreshold}
+}
+
+func (l *GormLogger) LogMode(mode logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.mode = mode
+	return &copied
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.mode == logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		sql, rows := fc()
+		return []any{"sql", sql, "rows", rows, "elapsed_ms", float64(elapsed.Microseconds()) / 1000}
+	}
+
+	switch {
+	// 查询不到记录由调用方处理，不视为错误
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		slog.ErrorContext(ctx, "sql error", append(attrs(), "error", err)...)
+	case l.mode >= logger.Warn || l.mode == 0:
+		if l.slowThreshold > 0 && elapsed > l.slowThreshold {
+			slog.WarnContext(ctx, "slow sql", append(attrs(), "threshold", l.slowThreshold.String())...)
+			return
+		}
+		if l.mode == logger.Info {
+			slog.InfoContext(ctx, "sql", attrs()...)
+		} else if slog.Default().Enabled(ctx, slog.LevelDebug) {
+			slog.DebugContext(ctx, "sql", attrs()...)
+		}
+	}
+}
+
+// ParamsFilter 日志中的 SQL 保留占位符，不填入参数值
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"prompt-manager/config"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type requestIDKey struct{}
+
+// Setup 按配置创建 slog 日志并设为默认日志，标准库 log 的输出也经由它写出
+// 返回日志的输出目标，供 gin 等直接写入 io.Writer 的组件使用
+func Setup(cfg config.LoggingConfig) (io.Writer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var writer io.Writer
+	switch cfg.Output {
+	case "", "stdout":
+		writer = os.Stdout
+	case "stderr":
+		writer = os.Stderr
+	default:
+		if err := os.MkdirAll(filepath.Dir(cfg.Output), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %v", err)
+		}
+		writer = &lumberjack.Logger{
+			Filename:   cfg.Output,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "", "text":
+		handler = slog.NewTextHandler(writer, options)
+	case "json":
+		handler = slog.NewJSONHandler(writer, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected text or json", cfg.Format)
+	}
+
+	// 设为默认日志后，标准库 log 的输出按 info 级别写入
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return writer, nil
+}
+
+// ParseLevel 解析 debug、info、warn、error 日志级别
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", level)
+	}
+}
+
+// WithRequestID 在 context 中记录请求 ID，使用该 context 写出的日志会带上 request_id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 获取 context 中的请求 ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 为日志补充 context 中的请求 ID
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"embed"
+	"flag"
 	"io/fs"
-	"log"
+	"log/slog"
+	"os"
 	"prompt-manager/config"
 	"prompt-manager/database"
 	"prompt-manager/handlers"
+	"prompt-manager/logging"
 	"prompt-manager/middleware"
+	"prompt-manager/services"
 	"strconv"
 	"strings"
 
@@ -18,22 +22,60 @@ import (
 var frontendFS embed.FS
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "执行数据库迁移后退出，不启动服务")
+	flag.Parse()
 
 	// 加载配置
 	cfg := config.LoadConfig()
 
+	// 按配置初始化日志，gin 的调试和错误输出写入同一目标
+	logWriter, err := logging.Setup(cfg.Logging)
+	if err != nil {
+		fatal("Failed to initialize logging", err)
+	}
+	gin.DefaultWriter, gin.DefaultErrorWriter = logWriter, logWriter
+	if os.Getenv(gin.EnvGinMode) == "" && !strings.EqualFold(cfg.Logging.Level, "debug") {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
 	// 初始化数据库
 	if err := database.InitDB(cfg); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", err)
 	}
 	defer database.CloseDB()
+	if *migrateOnly {
+		slog.Info("Database migrations completed")
+		return
+	}
+
+	// 初始化模型服务商客户端和价格表，并注册自定义服务商
+	services.InitLLMClient(cfg.Providers)
+	services.InitPricing(cfg.Pricing)
+	if err := handlers.LoadCustomProviders(); err != nil {
+		fatal("Failed to load custom providers", err)
+	}
+
+	// 按保留策略定期清理测试运行记录
+	handlers.StartRunCleanup(cfg.Runs.Retention)
+	handlers.StartTrashPurge(cfg.Trash.Retention)
+
+	// 上次退出时未完成的评测任务无法继续，标记为失败
+	if err := handlers.FailInterruptedEvaluations(); err != nil {
+		fatal("Failed to recover evaluations", err)
+	}
+	// 未完成的回归检查重新执行
+	if err := handlers.ResumePendingChecks(); err != nil {
+		fatal("Failed to resume prompt checks", err)
+	}
 
 	// 创建Gin实例
-	r := gin.Default()
+	r := gin.New()
 
-	// 全局中间件
-	r.Use(middleware.CORS())
+	// 全局中间件，请求 ID 需最先分配，后续日志才能带上
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Logger())
 	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.CORS())
 
 	// 初始化处理器
 	projectHandler := handlers.NewProjectHandler()
@@ -42,21 +84,68 @@ func main() {
 	categoryHandler := handlers.NewCategoryHandler()
 	exportHandler := handlers.NewExportHandler()
 	settingsHandler := handlers.NewSettingsHandler()
+	labelHandler := handlers.NewLabelHandler()
+	apiKeyHandler := handlers.NewAPIKeyHandler()
+	authHandler := handlers.NewAuthHandler(cfg.Auth.SessionTTL)
+	userHandler := handlers.NewUserHandler()
+	historyHandler := handlers.NewHistoryHandler()
+	promptEntityHandler := handlers.NewPromptEntityHandler()
+	auditHandler := handlers.NewAuditHandler()
+	providerHandler := handlers.NewProviderHandler()
+	runHandler := handlers.NewRunHandler()
+	datasetHandler := handlers.NewDatasetHandler()
+	evaluationHandler := handlers.NewEvaluationHandler(cfg.Evaluations.MaxConcurrency)
+	promptCheckHandler := handlers.NewPromptCheckHandler()
+	reviewHandler := handlers.NewReviewHandler()
+	trashHandler := handlers.NewTrashHandler(cfg.Trash.Retention)
+	searchHandler := handlers.NewSearchHandler()
+
+	if !cfg.Auth.Enabled {
+		slog.Warn("Authentication is disabled: anyone who can reach this port can read all projects and prompts without credentials, set auth.enabled to true to require login")
+	}
 
 	// API路由组
 	api := r.Group("/api")
+	api.Use(middleware.Audit(), middleware.Auth(cfg.Auth))
 	{
+		// 登录与用户管理
+		api.POST("/auth/login", authHandler.Login)
+		api.POST("/auth/logout", authHandler.Logout)
+		api.GET("/auth/me", authHandler.Me)
+		api.GET("/users", userHandler.GetUsers)
+		api.POST("/users", userHandler.CreateUser)
+		api.PUT("/users/:id", userHandler.UpdateUser)
+		api.DELETE("/users/:id", userHandler.DeleteUser)
+
+		// 审计日志
+		api.GET("/audit-logs", auditHandler.GetAuditLogs)
+		api.GET("/audit-logs/export", auditHandler.ExportAuditLogs)
+
 		// 设置管理
 		api.GET("/settings", settingsHandler.GetSettings)
 		api.POST("/settings", settingsHandler.UpdateSettings)
 		api.POST("/optimize-prompt", settingsHandler.OptimizePrompt)
 
+		// 自定义模型服务商
+		api.GET("/providers", providerHandler.GetProviders)
+		api.POST("/providers", providerHandler.CreateProvider)
+		api.PUT("/providers/:id", providerHandler.UpdateProvider)
+		api.DELETE("/providers/:id", providerHandler.DeleteProvider)
+
+		// API Key 管理
+		api.GET("/api-keys", apiKeyHandler.GetAPIKeys)
+		api.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+		api.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
 		// 项目管理
 		api.GET("/projects", projectHandler.GetProjects)
 		api.POST("/projects", projectHandler.CreateProject)
 		api.GET("/projects/:id", projectHandler.GetProject)
 		api.PUT("/projects/:id", projectHandler.UpdateProject)
 		api.DELETE("/projects/:id", projectHandler.DeleteProject)
+		api.GET("/projects/:id/members", userHandler.GetProjectMembers)
+		api.PUT("/projects/:id/members/:user_id", userHandler.SetProjectMember)
+		api.DELETE("/projects/:id/members/:user_id", userHandler.RemoveProjectMember)
 
 		// 提示词管理
 		api.GET("/projects/:id/prompts", promptHandler.GetPrompts) // 使用:id而不是:project_id
@@ -66,8 +155,43 @@ func main() {
 		api.DELETE("/prompts/:id", promptHandler.DeletePrompt)
 		api.GET("/prompts/:id/diff/:target_id", promptHandler.GetPromptDiff)
 		api.POST("/prompts/:id/rollback", promptHandler.RollbackPrompt)
+
+		// 提示词实体（每个提示词一条，包含其所有版本）
+		api.GET("/projects/:id/prompt-entities", promptEntityHandler.GetPromptEntities)
+		api.GET("/prompt-entities/:id", promptEntityHandler.GetPromptEntity)
+		api.PUT("/prompt-entities/:id", promptEntityHandler.UpdatePromptEntity)
+		api.GET("/prompt-entities/:id/versions", promptEntityHandler.GetPromptEntityVersions)
+
+		// 回归测试用例与版本检查
+		api.GET("/prompt-entities/:id/test-cases", promptCheckHandler.GetTestCases)
+		api.POST("/prompt-entities/:id/test-cases", promptCheckHandler.CreateTestCase)
+		api.PUT("/test-cases/:id", promptCheckHandler.UpdateTestCase)
+		api.DELETE("/test-cases/:id", promptCheckHandler.DeleteTestCase)
+		api.GET("/prompts/:id/check", promptCheckHandler.GetPromptCheck)
+		api.POST("/prompts/:id/check", promptCheckHandler.RunPromptCheck)
+		api.POST("/prompts/:id/check/accept", promptCheckHandler.AcceptPromptCheck)
+		api.PUT("/prompts/:id/status", promptHandler.UpdatePromptStatus)
+
+		// 变更审批
+		api.GET("/projects/:id/reviews", reviewHandler.GetProjectReviews)
+		api.PUT("/projects/:id/review-settings", reviewHandler.UpdateReviewSettings)
+		api.GET("/prompts/:id/review", reviewHandler.GetPromptReview)
+		api.POST("/prompts/:id/submit", reviewHandler.SubmitPromptReview)
+		api.POST("/prompts/:id/reviews", reviewHandler.CreatePromptReview)
+
+		// 操作历史
+		api.GET("/prompts/:id/history", historyHandler.GetPromptHistory)
+		api.GET("/projects/:id/history", historyHandler.GetProjectHistory)
+		api.POST("/history/:id/restore", historyHandler.RestoreHistory)
 		// SDK 获取提示词内容接口
 		api.GET("/projects/:id/sdk/prompt", promptHandler.GetSDKPrompt)
+		api.POST("/projects/:id/sdk/render", promptHandler.RenderSDKPrompt)
+
+		// 发布标签管理
+		api.GET("/projects/:id/labels", labelHandler.GetLabels)
+		api.POST("/projects/:id/labels", labelHandler.PromoteLabel)
+		api.DELETE("/projects/:id/labels/:label", labelHandler.DemoteLabel)
+		api.GET("/projects/:id/label-history", labelHandler.GetLabelHistory)
 
 		// 标签管理
 		api.GET("/tags", tagHandler.GetTags)
@@ -83,12 +207,45 @@ func main() {
 		api.PUT("/categories/:id", categoryHandler.UpdateCategory)
 		api.DELETE("/categories/:id", categoryHandler.DeleteCategory)
 
+		// 全文搜索
+		api.GET("/search", searchHandler.Search)
+
+		// 回收站
+		api.GET("/trash", trashHandler.GetTrash)
+		api.POST("/trash/:type/:id/restore", trashHandler.RestoreTrash)
+		api.DELETE("/trash/:type/:id", trashHandler.PurgeTrash)
+
 		// 导入导出
 		api.POST("/export", exportHandler.ExportData)
 		api.POST("/import", exportHandler.ImportData)
 
 		// 测试提示词
 		api.POST("/test-prompt", promptHandler.TestPrompt)
+
+		// 测试运行记录
+		api.GET("/runs", runHandler.GetRuns)
+		api.DELETE("/runs", runHandler.PurgeRuns)
+		api.GET("/runs/:id", runHandler.GetRun)
+		api.POST("/runs/:id/rerun", runHandler.RerunRun)
+		api.DELETE("/runs/:id", runHandler.DeleteRun)
+		api.GET("/prompts/:id/runs", runHandler.GetPromptRuns)
+
+		// 评测数据集与批量评测
+		api.GET("/projects/:id/datasets", datasetHandler.GetDatasets)
+		api.POST("/projects/:id/datasets", datasetHandler.CreateDataset)
+		api.GET("/datasets/:id", datasetHandler.GetDataset)
+		api.PUT("/datasets/:id", datasetHandler.UpdateDataset)
+		api.DELETE("/datasets/:id", datasetHandler.DeleteDataset)
+		api.POST("/datasets/:id/rows", datasetHandler.AddDatasetRows)
+		api.PUT("/datasets/:id/rows/:row_id", datasetHandler.UpdateDatasetRow)
+		api.DELETE("/datasets/:id/rows/:row_id", datasetHandler.DeleteDatasetRow)
+		api.GET("/datasets/:id/evaluations", evaluationHandler.GetEvaluations)
+		api.POST("/datasets/:id/evaluations", evaluationHandler.CreateEvaluation)
+		api.GET("/datasets/:id/compare", evaluationHandler.CompareEvaluations)
+		api.GET("/evaluations/:id", evaluationHandler.GetEvaluation)
+		api.GET("/evaluations/:id/results", evaluationHandler.GetEvaluationResults)
+		api.POST("/evaluations/:id/cancel", evaluationHandler.CancelEvaluation)
+		api.DELETE("/evaluations/:id", evaluationHandler.DeleteEvaluation)
 	}
 
 	// 健康检查
@@ -100,7 +257,7 @@ func main() {
 	frontendDist, err := fs.Sub(frontendFS, "dist")
 	if err != nil {
 		frontendDist = emptystorage{}
-		log.Printf("Warning: frontend assets not found, running in API-only mode")
+		slog.Warn("Frontend assets not found, running in API-only mode")
 	}
 
 	r.NoRoute(func(c *gin.Context) {
@@ -128,9 +285,9 @@ func main() {
 	})
 
 	// 启动服务器
-	log.Printf("Server starting on port %d...", cfg.Server.Port)
+	slog.Info("Server starting", "port", cfg.Server.Port)
 	if err := r.Run(":" + strconv.Itoa(cfg.Server.Port)); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatal("Failed to start server", err)
 	}
 }
 
@@ -144,14 +301,14 @@ func (emptystorage) Open(name string) (fs.File, error) {
 func getFileContent(fsys fs.FS, filename string) []byte {
 	file, err := fsys.Open(filename)
 	if err != nil {
-		log.Printf("Error opening file %s: %v", filename, err)
+		slog.Error("Failed to open file", "file", filename, "error", err)
 		return []byte("<html><body><h1>File not found</h1></body></html>")
 	}
 	defer file.Close()
 
 	content, err := fs.ReadFile(fsys, filename)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filename, err)
+		slog.Error("Failed to read file", "file", filename, "error", err)
 		return []byte("<html><body><h1>Error reading file</h1></body></html>")
 	}
 
@@ -190,3 +347,9 @@ func getContentType(ext string) string {
 		return "application/octet-stream"
 	}
 }
+
+// fatal 以 error 级别记录启动失败的原因后退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyContextKey 认证通过后 API Key 在 gin.Context 中的键名
+const APIKeyContextKey = "api_key"
+
+// authenticateAPIKey 校验 API Key 的有效性、项目范围和读写权限，失败时中止请求
+func authenticateAPIKey(c *gin.Context, plain string) bool {
+	var key models.APIKey
+	if err := database.DB.Preload("Projects").Where("key_hash = ?", services.HashToken(plain)).First(&key).Error; err != nil || key.RevokedAt != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return false
+	}
+
+	projectID, ok := resolveProjectID(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key cannot access this resource"})
+		return false
+	}
+	if !keyHasProject(&key, projectID) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is not allowed to access this project"})
+		return false
+	}
+	if requiresWrite(c) && key.Permission != "write" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is read-only"})
+		return false
+	}
+
+	now := time.Now()
+	database.DB.Model(&key).UpdateColumn("last_used_at", now)
+	key.LastUsedAt = &now
+
+	c.Set(APIKeyContextKey, &key)
+	return true
+}
+
+// extractAPIKey 从 X-API-Key 或 Authorization: Bearer 请求头中读取 API Key
+func extractAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key
+	}
+	auth := c.GetHeader("Authorization")
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		token = strings.TrimSpace(token)
+		if strings.HasPrefix(token, services.APIKeyPrefix) {
+			return token
+		}
+	}
+	return ""
+}
+
+// resolveProjectID 根据路由确定请求所属的项目，非项目级路由返回 false
+func resolveProjectID(c *gin.Context) (string, bool) {
+	route := c.FullPath()
+	switch {
+	case strings.HasPrefix(route, "/api/projects/:id"):
+		return c.Param("id"), true
+	case strings.HasPrefix(route, "/api/prompts/:id"):
+		var prompt models.Prompt
+		if err := database.DB.Select("project_id").First(&prompt, "id = ?", c.Param("id")).Error; err != nil {
+			return "", false
+		}
+		return prompt.ProjectID, true
+	case strings.HasPrefix(route, "/api/prompt-entities/:id"):
+		var entity models.PromptEntity
+		if err := database.DB.Select("project_id").First(&entity, "id = ?", c.Param("id")).Error; err != nil {
+			return "", false
+		}
+		return entity.ProjectID, true
+	case strings.HasPrefix(route, "/api/test-cases/:id"):
+		var projectID string
+		if err := database.DB.Model(&models.PromptTestCase{}).
+			Joins("JOIN prompt_entities ON prompt_entities.id = prompt_test_cases.entity_id").
+			Where("prompt_test_cases.id = ?", c.Param("id")).
+			Pluck("prompt_entities.project_id", &projectID).Error; err != nil || projectID == "" {
+			return "", false
+		}
+		return projectID, true
+	case strings.HasPrefix(route, "/api/history/:id"):
+		var projectID string
+		if err := database.DB.Model(&models.PromptHistory{}).
+			Joins("JOIN prompts ON prompts.id = prompt_histories.prompt_id").
+			Where("prompt_histories.id = ?", c.Param("id")).
+			Pluck("prompts.project_id", &projectID).Error; err != nil || projectID == "" {
+			return "", false
+		}
+		return projectID, true
+	case strings.HasPrefix(route, "/api/datasets/:id"):
+		var dataset models.Dataset
+		if err := database.DB.Select("project_id").First(&dataset, "id = ?", c.Param("id")).Error; err != nil {
+			return "", false
+		}
+		return dataset.ProjectID, true
+	case strings.HasPrefix(route, "/api/evaluations/:id"):
+		var evaluation models.Evaluation
+		if err := database.DB.Select("project_id").First(&evaluation, "id = ?", c.Param("id")).Error; err != nil {
+			return "", false
+		}
+		return evaluation.ProjectID, true
+	case strings.HasPrefix(route, "/api/runs/:id"):
+		var run models.TestRun
+		if err := database.DB.Select("project_id").First(&run, "id = ?", c.Param("id")).Error; err != nil || run.ProjectID == "" {
+			return "", false
+		}
+		return run.ProjectID, true
+	default:
+		return "", false
+	}
+}
+
+// requiresWrite 判断请求是否需要写权限，SDK 接口均视为只读
+func requiresWrite(c *gin.Context) bool {
+	if strings.HasPrefix(c.FullPath(), "/api/projects/:id/sdk/") {
+		return false
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+func keyHasProject(key *models.APIKey, projectID string) bool {
+	for _, p := range key.Projects {
+		if p.ID == projectID {
+			return true
+		}
+	}
+	return false
+}
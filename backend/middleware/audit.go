@@ -0,0 +1,330 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAuditBodySize 审计时最多缓存的响应体大小，超出部分不记录快照
+const maxAuditBodySize = 64 * 1024
+
+// auditActions 路由中表示动作而非实体的片段，确定实体类型时跳过
+var auditActions = map[string]bool{
+	"login":           true,
+	"logout":          true,
+	"rollback":        true,
+	"restore":         true,
+	"render":          true,
+	"rerun":           true,
+	"cancel":          true,
+	"check":           true,
+	"accept":          true,
+	"submit":          true,
+	"status":          true,
+	"review-settings": true,
+}
+
+// auditModels 可按 /api/<type>/:id 加载快照的实体
+var auditModels = map[string]func() any{
+	"projects":        func() any { return &models.Project{} },
+	"prompts":         func() any { return &models.Prompt{} },
+	"prompt-entities": func() any { return &models.PromptEntity{} },
+	"tags":            func() any { return &models.Tag{} },
+	"categories":      func() any { return &models.Category{} },
+	"users":           func() any { return &models.User{} },
+	"api-keys":        func() any { return &models.APIKey{} },
+	"providers":       func() any { return &models.CustomProvider{} },
+	"runs":            func() any { return &models.TestRun{} },
+	"datasets":        func() any { return &models.Dataset{} },
+	"evaluations":     func() any { return &models.Evaluation{} },
+	"test-cases":      func() any { return &models.PromptTestCase{} },
+}
+
+// sensitiveFields 快照中需要脱敏的字段
+var sensitiveFields = map[string]bool{
+	"key":           true,
+	"token":         true,
+	"password":      true,
+	"password_hash": true,
+	"key_hash":      true,
+	"token_hash":    true,
+}
+
+// auditWriter 在写出响应的同时缓存响应体
+type auditWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	if !w.truncated {
+		if w.body.Len()+len(data) > maxAuditBodySize {
+			w.truncated = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Audit 审计中间件，为 /api 下所有 POST/PUT/DELETE 请求追加一条审计日志
+// 需挂载在 Auth 之前，这样被拒绝的请求也会留下记录。
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPut && method != http.MethodDelete {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		entityType, entityID := auditTarget(c, route)
+		before := auditSnapshot(route, entityType, entityID)
+
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		entry := models.AuditLog{
+			Actor:      CurrentActor(c),
+			IP:         c.ClientIP(),
+			Method:     method,
+			Route:      route,
+			Path:       c.Request.URL.Path,
+			EntityType: entityType,
+			EntityID:   entityID,
+			StatusCode: writer.Status(),
+			Before:     before,
+			CreatedAt:  time.Now(),
+		}
+
+		if writer.Status() < http.StatusBadRequest {
+			// 更新提示词会生成新版本，因此优先记录响应中的实体，响应不是实体时再重新读取
+			switch {
+			case method == http.MethodDelete:
+			case entityType == "settings":
+				entry.After = settingsSnapshot()
+			default:
+				entry.After = responseSnapshot(writer)
+				if entry.After == "" && method == http.MethodPut {
+					entry.After = auditSnapshot(route, entityType, entityID)
+				}
+			}
+			if entry.EntityID == "" && method == http.MethodPost {
+				entry.EntityID = responseEntityID(writer)
+			}
+		}
+		if entry.EntityID == "" && c.Request.MultipartForm != nil {
+			entry.EntityID = uploadedFileNames(c)
+		}
+
+		if err := database.DB.Create(&entry).Error; err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to write audit log", "error", err)
+		}
+	}
+}
+
+// CurrentActor 返回当前请求的认证身份：登录用户名或 api_key:<名称>，匿名请求返回空字符串
+func CurrentActor(c *gin.Context) string {
+	if value, ok := c.Get(UserContextKey); ok {
+		if user, ok := value.(*models.User); ok {
+			return user.Username
+		}
+	}
+	if value, ok := c.Get(APIKeyContextKey); ok {
+		if key, ok := value.(*models.APIKey); ok {
+			return "api_key:" + key.Name
+		}
+	}
+	return ""
+}
+
+// auditTarget 根据路由推断目标实体：实体类型取最后一个非动作的静态片段，实体 ID 取最后一个路由参数
+// 例如 /api/projects/:id/labels/:label 对应 labels 实体，/api/prompts/:id/rollback 对应 prompts 实体
+func auditTarget(c *gin.Context, route string) (entityType, entityID string) {
+	segments := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+		if strings.HasPrefix(segment, ":") {
+			if entityID == "" && entityType == "" {
+				entityID = c.Param(segment[1:])
+			}
+			continue
+		}
+		if !auditActions[segment] {
+			entityType = segment
+			break
+		}
+	}
+
+	// 在集合上创建子资源（如 POST /api/projects/:id/prompts）时，路由参数属于父级，不作为实体 ID
+	if strings.HasSuffix(route, "/"+entityType) {
+		entityID = ""
+	}
+	return entityType, entityID
+}
+
+// auditSnapshot 读取目标实体当前状态的 JSON 快照，无法加载时返回空字符串
+func auditSnapshot(route, entityType, entityID string) string {
+	if entityType == "settings" {
+		return settingsSnapshot()
+	}
+
+	newModel, ok := auditModels[entityType]
+	if !ok || entityID == "" || !strings.Contains(route, "/"+entityType+"/:id") {
+		return ""
+	}
+
+	row := map[string]any{}
+	if err := database.DB.Model(newModel()).Where("id = ?", entityID).Take(&row).Error; err != nil {
+		return ""
+	}
+	return marshalSnapshot(row)
+}
+
+// settingsSnapshot 读取全部设置，API Key 等敏感值只保留末尾几位
+func settingsSnapshot() string {
+	var settings []models.Setting
+	if err := database.DB.Find(&settings).Error; err != nil {
+		return ""
+	}
+	values := make(map[string]any, len(settings))
+	for _, s := range settings {
+		if isSecretSetting(s.Key) {
+			values[s.Key] = maskSecret(s.Value)
+		} else {
+			values[s.Key] = s.Value
+		}
+	}
+	return marshalSnapshot(values)
+}
+
+// responseSnapshot 将 JSON 响应体脱敏后作为快照，非 JSON 或过大的响应不记录
+func responseSnapshot(w *auditWriter) string {
+	if w.truncated || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return ""
+	}
+	var body any
+	if err := json.Unmarshal(w.body.Bytes(), &body); err != nil {
+		return ""
+	}
+	return marshalSnapshot(body)
+}
+
+// responseEntityID 从创建接口的响应中读取新实体的 ID
+func responseEntityID(w *auditWriter) string {
+	if w.truncated {
+		return ""
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.body.Bytes(), &body); err != nil {
+		return ""
+	}
+	if id, ok := body["id"].(string); ok {
+		return id
+	}
+	// 部分接口将实体包在一层对象中，例如 {"api_key": {...}, "key": "..."}
+	for _, value := range body {
+		if nested, ok := value.(map[string]any); ok {
+			if id, ok := nested["id"].(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+func uploadedFileNames(c *gin.Context) string {
+	var names []string
+	for _, files := range c.Request.MultipartForm.File {
+		for _, file := range files {
+			names = append(names, file.Filename)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func marshalSnapshot(value any) string {
+	data, err := json.Marshal(redact(value))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// redact 递归脱敏快照中的敏感字段
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if sensitiveFields[k] {
+				if s, ok := item.(string); ok && s != "" {
+					v[k] = maskSecret(s)
+				}
+				continue
+			}
+			if s, ok := item.(string); ok && isSecretSetting(k) {
+				v[k] = maskSecret(s)
+				continue
+			}
+			if k == "extra_headers" {
+				v[k] = maskHeaders(item)
+				continue
+			}
+			v[k] = redact(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// maskHeaders 自定义服务商的附加请求头可能携带鉴权信息，全部脱敏
+// 从数据库加载的快照中该字段为 JSON 文本，响应体中为对象
+func maskHeaders(value any) any {
+	headers, ok := value.(map[string]any)
+	if s, isText := value.(string); isText {
+		ok = json.Unmarshal([]byte(s), &headers) == nil
+	}
+	if !ok {
+		return value
+	}
+	for name, header := range headers {
+		if s, ok := header.(string); ok && s != "" {
+			headers[name] = maskSecret(s)
+		}
+	}
+	return headers
+}
+
+func isSecretSetting(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasSuffix(key, "api_key") || strings.Contains(key, "secret") || strings.Contains(key, "password")
+}
+
+// maskSecret 只保留末尾 4 位
+func maskSecret(value string) string {
+	if len(value) <= 4 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
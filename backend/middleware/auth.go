@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"net/http"
+	"prompt-manager/config"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserContextKey 登录用户在 gin.Context 中的键名
+const UserContextKey = "user"
+
+// publicRoutes 无需认证即可访问的接口
+var publicRoutes = map[string]bool{
+	"POST /api/auth/login": true,
+}
+
+// adminRoutePrefixes 仅管理员可访问的接口前缀
+var adminRoutePrefixes = []string{
+	"/api/users",
+	"/api/api-keys",
+	"/api/settings",
+	"/api/audit-logs",
+}
+
+// Auth 认证与鉴权中间件，包裹整个 /api 路由组
+// 支持 API Key 和登录会话两种凭证；未开启认证时，不携带凭证的请求只能读取，
+// 写操作和仅管理员可访问的接口始终需要凭证。API Key 不能访问仅管理员可访问的接口。
+func Auth(cfg config.AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if publicRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		required := requiredRole(c)
+		if plain := extractAPIKey(c); plain != "" {
+			if required == services.RoleAdmin {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key cannot access this resource"})
+				return
+			}
+			if authenticateAPIKey(c, plain) {
+				c.Next()
+			}
+			return
+		}
+
+		token := extractSessionToken(c)
+		if token == "" {
+			if cfg.Enabled || required == services.RoleAdmin || requiresWrite(c) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		user, ok := authenticateSession(token)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired session"})
+			return
+		}
+
+		if !services.RoleAtLeast(effectiveRole(c, user), required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions, " + required + " role required"})
+			return
+		}
+
+		c.Set(UserContextKey, user)
+		c.Next()
+	}
+}
+
+// extractSessionToken 从 Authorization: Bearer 请求头中读取会话令牌
+func extractSessionToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	token = strings.TrimSpace(token)
+	if !strings.HasPrefix(token, services.SessionTokenPrefix) {
+		return ""
+	}
+	return token
+}
+
+func authenticateSession(token string) (*models.User, bool) {
+	var session models.Session
+	if err := database.DB.Where("token_hash = ?", services.HashToken(token)).First(&session).Error; err != nil {
+		return nil, false
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", session.UserID).Error; err != nil || user.Disabled {
+		return nil, false
+	}
+	return &user, true
+}
+
+// requiredRole 计算访问当前路由所需的最低角色
+func requiredRole(c *gin.Context) string {
+	route := c.FullPath()
+	if strings.HasPrefix(route, "/api/auth/") {
+		return services.RoleViewer
+	}
+	for _, prefix := range adminRoutePrefixes {
+		if strings.HasPrefix(route, prefix) {
+			return services.RoleAdmin
+		}
+	}
+	if strings.HasPrefix(route, "/api/projects/:id/members") && c.Request.Method != http.MethodGet {
+		return services.RoleAdmin
+	}
+	if route == "/api/projects/:id" && c.Request.Method == http.MethodDelete {
+		return services.RoleAdmin
+	}
+	if route == "/api/projects/:id/review-settings" {
+		return services.RoleAdmin
+	}
+	if strings.HasPrefix(route, "/api/providers") && c.Request.Method != http.MethodGet {
+		return services.RoleAdmin
+	}
+	if route == "/api/runs" && c.Request.Method == http.MethodDelete {
+		return services.RoleAdmin
+	}
+	if route == "/api/trash/:type/:id" && c.Request.Method == http.MethodDelete {
+		return services.RoleAdmin
+	}
+	if requiresWrite(c) {
+		return services.RoleEditor
+	}
+	return services.RoleViewer
+}
+
+// effectiveRole 全局管理员始终为 admin；项目级路由优先使用项目成员角色
+func effectiveRole(c *gin.Context, user *models.User) string {
+	if user.Role == services.RoleAdmin {
+		return user.Role
+	}
+	projectID, ok := resolveProjectID(c)
+	if !ok {
+		return user.Role
+	}
+	var member models.ProjectMember
+	if err := database.DB.Where("project_id = ? AND user_id = ?", projectID, user.ID).First(&member).Error; err == nil {
+		return member.Role
+	}
+	return user.Role
+}
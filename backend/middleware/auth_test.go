@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"prompt-manager/config"
+	"prompt-manager/database"
+	"prompt-manager/models"
+	"prompt-manager/services"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	database.DB = db
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { database.CloseDB() })
+}
+
+// newAuthRouter 注册 requiredRole 涉及的几类路由，处理函数直接返回 200
+func newAuthRouter(cfg config.AuthConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(Auth(cfg))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.GET("/projects", ok)
+	api.POST("/users", ok)
+	api.POST("/api-keys", ok)
+	api.DELETE("/projects/:id", ok)
+	api.GET("/projects/:id/prompts", ok)
+	api.POST("/projects/:id/prompts", ok)
+	api.PUT("/projects/:id/members/:user_id", ok)
+	api.DELETE("/projects/:id/members/:user_id", ok)
+	api.PUT("/projects/:id/review-settings", ok)
+	api.GET("/projects/:id/sdk/prompt", ok)
+	api.PUT("/prompts/:id", ok)
+	api.DELETE("/prompts/:id", ok)
+	return r
+}
+
+func serve(r *gin.Engine, method, path string, header http.Header) int {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAPIKeyCannotAccessAdminRoutes(t *testing.T) {
+	setupTestDB(t)
+	project := models.Project{Name: "p", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := database.DB.Create(&project).Error; err != nil {
+		t.Fatal(err)
+	}
+	plain, prefix, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := models.APIKey{Name: "k", KeyPrefix: prefix, KeyHash: hash, Permission: "write", CreatedAt: time.Now(),
+		Projects: []models.Project{project}}
+	if err := database.DB.Create(&key).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newAuthRouter(config.AuthConfig{Enabled: true})
+	header := http.Header{"X-Api-Key": {plain}}
+	base := "/api/projects/" + project.ID
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, base + "/prompts", http.StatusOK},
+		{http.MethodPost, base + "/prompts", http.StatusOK},
+		{http.MethodPut, base + "/members/u1", http.StatusForbidden},
+		{http.MethodDelete, base + "/members/u1", http.StatusForbidden},
+		{http.MethodPut, base + "/review-settings", http.StatusForbidden},
+		{http.MethodDelete, base, http.StatusForbidden},
+		{http.MethodPost, "/api/api-keys", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if got := serve(r, tc.method, tc.path, header); got != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+// 未开启认证时，不携带凭证的请求只能读取
+func TestAnonymousRequestsAreReadOnlyWhenAuthDisabled(t *testing.T) {
+	setupTestDB(t)
+	r := newAuthRouter(config.AuthConfig{Enabled: false})
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/projects", http.StatusOK},
+		{http.MethodGet, "/api/projects/p1/prompts", http.StatusOK},
+		{http.MethodGet, "/api/projects/p1/sdk/prompt", http.StatusOK},
+		{http.MethodPost, "/api/projects/p1/prompts", http.StatusUnauthorized},
+		{http.MethodPut, "/api/prompts/v1", http.StatusUnauthorized},
+		{http.MethodDelete, "/api/prompts/v1", http.StatusUnauthorized},
+		{http.MethodPost, "/api/users", http.StatusUnauthorized},
+		{http.MethodPost, "/api/api-keys", http.StatusUnauthorized},
+		{http.MethodPut, "/api/projects/p1/review-settings", http.StatusUnauthorized},
+		{http.MethodDelete, "/api/projects/p1", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := serve(r, tc.method, tc.path, nil); got != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"prompt-manager/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDContextKey 请求 ID 在 gin.Context 中的键名
+const RequestIDContextKey = "request_id"
+
+// maxRequestIDLength 沿用客户端传入的请求 ID 时允许的最大长度
+const maxRequestIDLength = 64
+
+// RequestID 为每个请求分配请求 ID：沿用客户端传入的 X-Request-ID，没有或不合法时生成新的 ID，
+// 并写入响应头和请求的 context，使用 c.Request.Context() 写出的日志会带上 request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(RequestIDContextKey, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID 只接受字母、数字和 - _ . 组成的 ID，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -1,7 +1,12 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -14,27 +19,298 @@ type Project struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	Prompts     []Prompt  `json:"prompts" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
 	Tags        []Tag     `json:"tags" gorm:"many2many:project_tags"`
+	// PromptEntities 项目下的提示词（每个名称一条），Prompts 中为全部版本
+	PromptEntities []PromptEntity `json:"prompt_entities,omitempty" gorm:"foreignKey:ProjectID"`
+	// RequiredApprovals 提示词新版本发布前所需的审批人数，0 表示无需审批
+	RequiredApprovals int `json:"required_approvals" gorm:"default:0"`
+	// DeletedAt 移入回收站的时间，项目下的提示词版本随项目一起移入回收站
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// PromptEntity 提示词实体，同一提示词的各个版本（prompts 表中的记录）通过 EntityID 归属于同一个实体
+type PromptEntity struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ProjectID   string    `json:"project_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_entity_name"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_entity_name"`
+	Description string    `json:"description" gorm:"type:text"`
+	Category    string    `json:"category" gorm:"type:varchar(50);index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Tags        []Tag     `json:"tags,omitempty" gorm:"many2many:prompt_entity_tags"`
 }
 
 type Prompt struct {
     ID          string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
-    ProjectID   string         `json:"project_id" gorm:"type:varchar(36);not null;index"`
-    Name        string         `json:"name" gorm:"type:varchar(100);default:'';index"`
-    Version     string         `json:"version" gorm:"type:varchar(20);not null;index"`
+    ProjectID   string         `json:"project_id" gorm:"type:varchar(36);not null;index;uniqueIndex:idx_prompt_version"`
+    EntityID    string         `json:"entity_id" gorm:"type:varchar(36);index"`
+    Name        string         `json:"name" gorm:"type:varchar(100);default:'';index;uniqueIndex:idx_prompt_version"`
+    Version     string         `json:"version" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_prompt_version"`
     Content     string         `json:"content" gorm:"type:text;not null"`
     Description string         `json:"description" gorm:"type:text"`
     Category    string         `json:"category" gorm:"type:varchar(50);index"`
+    Variables   PromptVariables `json:"variables" gorm:"type:text"`
+    Assertions  Assertions     `json:"assertions" gorm:"type:text"` // 测试时默认使用的断言
+    CheckStatus string         `json:"check_status" gorm:"type:varchar(20);default:'';index"` // 回归检查状态：空（无测试用例）|pending|passed|failed
+    CheckAccepted bool         `json:"check_accepted" gorm:"default:false"` // 回归检查未通过时是否已强制接受
+    ReviewStatus string        `json:"review_status" gorm:"type:varchar(20);default:'';index"` // 审批状态：空（无需审批）|draft|in_review|approved|rejected
+    BaseID      string         `json:"base_id" gorm:"type:varchar(36)"` // 提议版本基于的版本，用于审批时对比差异
+    Status      string         `json:"status" gorm:"type:varchar(20);default:'published';index"` // 生命周期状态：draft|published|deprecated|archived
+    DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"` // 移入回收站的时间，标签关联与历史记录在彻底删除前保留
     CreatedAt   time.Time      `json:"created_at"`
     Project     Project        `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
     Tags        []Tag          `json:"tags,omitempty" gorm:"many2many:prompt_tags"`
     History     []PromptHistory `json:"history,omitempty" gorm:"foreignKey:PromptID;constraint:OnDelete:CASCADE"`
 }
 
+// PromptVariable 提示词模板变量定义
+type PromptVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // string|number|integer|boolean|array|object
+	Required    bool   `json:"required"`
+	Default     any    `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PromptVariables 以 JSON 文本形式存储的变量列表
+type PromptVariables []PromptVariable
+
+func (v PromptVariables) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (v *PromptVariables) Scan(value interface{}) error {
+	var data []byte
+	switch val := value.(type) {
+	case nil:
+		*v = PromptVariables{}
+		return nil
+	case string:
+		data = []byte(val)
+	case []byte:
+		data = val
+	default:
+		return fmt.Errorf("unsupported type for PromptVariables: %T", value)
+	}
+	if len(data) == 0 {
+		*v = PromptVariables{}
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Assertion 对模型输出的断言，Type 为 equals|contains|not_contains|regex|json_schema|length|llm_judge
+type Assertion struct {
+	Name       string          `json:"name,omitempty"`
+	Type       string          `json:"type"`
+	Value      string          `json:"value,omitempty"`       // equals、contains、not_contains 的期望文本，regex 的正则表达式
+	IgnoreCase bool            `json:"ignore_case,omitempty"` // 文本比较和正则匹配时忽略大小写
+	Schema     json.RawMessage `json:"schema,omitempty"`      // json_schema 的 JSON Schema，为空时只校验输出是否为合法 JSON
+	Min        *int            `json:"min,omitempty"`         // length 的最少字符数
+	Max        *int            `json:"max,omitempty"`         // length 的最多字符数
+	Rubric     string          `json:"rubric,omitempty"`      // llm_judge 的评分标准
+	Provider   string          `json:"provider,omitempty"`    // llm_judge 使用的服务商，为空时与被测模型相同
+	Model      string          `json:"model,omitempty"`       // llm_judge 使用的模型，为空时使用服务商的默认模型
+	Threshold  *float64        `json:"threshold,omitempty"`   // llm_judge 通过所需的最低得分（0-1），默认 0.7
+	Weight     float64         `json:"weight,omitempty"`      // 计算总分时的权重，默认 1
+}
+
+// Assertions 以 JSON 文本形式存储的断言列表
+type Assertions []Assertion
+
+func (a Assertions) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (a *Assertions) Scan(value interface{}) error {
+	data, err := jsonColumnBytes(value, "Assertions")
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*a = Assertions{}
+		return nil
+	}
+	return json.Unmarshal(data, a)
+}
+
+// AssertionOutcome 单条断言的执行结果
+type AssertionOutcome struct {
+	Name   string  `json:"name,omitempty"`
+	Type   string  `json:"type"`
+	Passed bool    `json:"passed"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// AssertionOutcomes 以 JSON 文本形式存储的断言结果列表
+type AssertionOutcomes []AssertionOutcome
+
+func (o AssertionOutcomes) Value() (driver.Value, error) {
+	if o == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (o *AssertionOutcomes) Scan(value interface{}) error {
+	data, err := jsonColumnBytes(value, "AssertionOutcomes")
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*o = AssertionOutcomes{}
+		return nil
+	}
+	return json.Unmarshal(data, o)
+}
+
+// StringList 以 JSON 文本形式存储的字符串列表
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	data, err := jsonColumnBytes(value, "StringList")
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*l = StringList{}
+		return nil
+	}
+	return json.Unmarshal(data, l)
+}
+
+// StringMap 以 JSON 文本形式存储的键值对
+type StringMap map[string]string
+
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *StringMap) Scan(value interface{}) error {
+	data, err := jsonColumnBytes(value, "StringMap")
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*m = StringMap{}
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+// VariableValues 以 JSON 文本形式存储的模板变量取值
+type VariableValues map[string]any
+
+func (v VariableValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (v *VariableValues) Scan(value interface{}) error {
+	data, err := jsonColumnBytes(value, "VariableValues")
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*v = VariableValues{}
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ChatMessage 一条对话消息
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatMessages 以 JSON 文本形式存储的对话消息列表
+type ChatMessages []ChatMessage
+
+func (m ChatMessages) Value() (driver.Value, error) {
+	if m == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *ChatMessages) Scan(value interface{}) error {
+	data, err := jsonColumnBytes(value, "ChatMessages")
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*m = ChatMessages{}
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+func jsonColumnBytes(value interface{}, typeName string) ([]byte, error) {
+	switch val := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(val), nil
+	case []byte:
+		return val, nil
+	default:
+		return nil, fmt.Errorf("unsupported type for %s: %T", typeName, value)
+	}
+}
+
 type Tag struct {
     ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
     Name      string    `json:"name" gorm:"type:varchar(50);not null;unique"`
     Color     string    `json:"color" gorm:"type:varchar(7);default:'#3b82f6'"`
     CreatedAt time.Time `json:"created_at"`
+    DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
     Projects  []Project `json:"projects,omitempty" gorm:"many2many:project_tags"`
     Prompts   []Prompt  `json:"prompts,omitempty" gorm:"many2many:prompt_tags"`
 }
@@ -44,6 +320,7 @@ type Category struct {
     Name      string    `json:"name" gorm:"type:varchar(50);not null;unique"`
     Color     string    `json:"color" gorm:"type:varchar(7);default:'#6366f1'"`
     CreatedAt time.Time `json:"created_at"`
+    DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type PromptHistory struct {
@@ -52,10 +329,271 @@ type PromptHistory struct {
 	Operation  string    `json:"operation" gorm:"type:varchar(20);not null"`
 	OldContent string    `json:"old_content" gorm:"type:text"`
 	NewContent string    `json:"new_content" gorm:"type:text"`
+	Author     string    `json:"author" gorm:"type:varchar(100)"`
 	CreatedAt  time.Time `json:"created_at"`
 	Prompt     Prompt    `json:"prompt,omitempty" gorm:"foreignKey:PromptID"`
 }
 
+// PromptLabel 发布标签，指向某个提示词名称下的具体版本
+
+type PromptLabel struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ProjectID  string    `json:"project_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_prompt_label"`
+	PromptName string    `json:"prompt_name" gorm:"type:varchar(100);not null;uniqueIndex:idx_prompt_label"`
+	Label      string    `json:"label" gorm:"type:varchar(50);not null;uniqueIndex:idx_prompt_label"`
+	PromptID   string    `json:"prompt_id" gorm:"type:varchar(36);not null;index"`
+	Version    string    `json:"version" gorm:"type:varchar(50);not null"`
+	UpdatedBy  string    `json:"updated_by" gorm:"type:varchar(100)"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PromptLabelHistory 发布标签的移动记录
+
+type PromptLabelHistory struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ProjectID    string    `json:"project_id" gorm:"type:varchar(36);not null;index"`
+	PromptName   string    `json:"prompt_name" gorm:"type:varchar(100);not null;index"`
+	Label        string    `json:"label" gorm:"type:varchar(50);not null"`
+	Operation    string    `json:"operation" gorm:"type:varchar(20);not null"` // promote|demote
+	FromPromptID string    `json:"from_prompt_id" gorm:"type:varchar(36)"`
+	FromVersion  string    `json:"from_version" gorm:"type:varchar(50)"`
+	ToPromptID   string    `json:"to_prompt_id" gorm:"type:varchar(36)"`
+	ToVersion    string    `json:"to_version" gorm:"type:varchar(50)"`
+	Actor        string    `json:"actor" gorm:"type:varchar(100)"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// APIKey 项目级 API Key，仅保存哈希值
+type APIKey struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	KeyPrefix  string     `json:"key_prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Permission string     `json:"permission" gorm:"type:varchar(10);not null"` // read|write
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Projects   []Project  `json:"projects,omitempty" gorm:"many2many:api_key_projects"`
+}
+
+// User 系统用户，Role 为全局角色 admin|editor|viewer
+type User struct {
+	ID           string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Username     string     `json:"username" gorm:"type:varchar(50);not null;uniqueIndex"`
+	PasswordHash string     `json:"-" gorm:"type:varchar(100);not null"`
+	Role         string     `json:"role" gorm:"type:varchar(10);not null"`
+	Disabled     bool       `json:"disabled" gorm:"not null;default:false"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ProjectMember 用户在单个项目中的角色，优先于全局角色
+type ProjectMember struct {
+	ProjectID string    `json:"project_id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string    `json:"user_id" gorm:"primaryKey;type:varchar(36);index"`
+	Role      string    `json:"role" gorm:"type:varchar(10);not null"`
+	CreatedAt time.Time `json:"created_at"`
+	User      User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Session 登录会话，仅保存令牌哈希
+type Session struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	TokenHash string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(36);not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLog 全局审计日志，只允许追加
+type AuditLog struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Actor      string    `json:"actor" gorm:"type:varchar(100);index"`
+	IP         string    `json:"ip" gorm:"type:varchar(64)"`
+	Method     string    `json:"method" gorm:"type:varchar(10);not null"`
+	Route      string    `json:"route" gorm:"type:varchar(255);not null"`
+	Path       string    `json:"path" gorm:"type:varchar(512);not null"`
+	EntityType string    `json:"entity_type" gorm:"type:varchar(50);index"`
+	EntityID   string    `json:"entity_id" gorm:"type:varchar(255);index"`
+	StatusCode int       `json:"status_code"`
+	Before     string    `json:"before" gorm:"type:text"`
+	After      string    `json:"after" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// CustomProvider 用户自定义的 OpenAI 兼容模型服务商
+// AuthScheme 为 bearer（Authorization: Bearer）、header（AuthHeader 指定的请求头）或 none
+type CustomProvider struct {
+	ID           string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name         string     `json:"name" gorm:"type:varchar(50);not null;uniqueIndex"`
+	DisplayName  string     `json:"display_name" gorm:"type:varchar(100)"`
+	BaseURL      string     `json:"base_url" gorm:"type:varchar(500);not null"`
+	AuthScheme   string     `json:"auth_scheme" gorm:"type:varchar(10);not null;default:'bearer'"`
+	AuthHeader   string     `json:"auth_header" gorm:"type:varchar(100)"`
+	DefaultModel string     `json:"default_model" gorm:"type:varchar(100)"`
+	Models       StringList `json:"models" gorm:"type:text"`
+	ExtraHeaders StringMap  `json:"extra_headers" gorm:"type:text"`
+	StreamUsage  bool       `json:"stream_usage" gorm:"not null;default:false"` // 流式请求是否发送 stream_options.include_usage
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TestRun 测试台的一次模型调用记录，Kind 为 test|optimize，Status 为 success|error|canceled
+// Model 为请求的模型，重新运行时使用；ResponseModel 为服务商实际返回的模型
+type TestRun struct {
+	ID                 string       `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Kind               string       `json:"kind" gorm:"type:varchar(20);not null;index"`
+	ProjectID          string       `json:"project_id" gorm:"type:varchar(36);index"`
+	PromptID           string       `json:"prompt_id" gorm:"type:varchar(36);index"`
+	RerunOf            string       `json:"rerun_of" gorm:"type:varchar(36)"`
+	Provider           string       `json:"provider" gorm:"type:varchar(50);not null;index"`
+	Model              string       `json:"model" gorm:"type:varchar(100)"`
+	Temperature        *float64     `json:"temperature"`
+	TopP               *float64     `json:"top_p"`
+	MaxTokens          int          `json:"max_tokens"`
+	Stream             bool         `json:"stream"`
+	Messages           ChatMessages `json:"messages" gorm:"type:text"`
+	Response           string       `json:"response" gorm:"type:text"`
+	Status             string       `json:"status" gorm:"type:varchar(20);not null;index"`
+	Error              string       `json:"error" gorm:"type:text"`
+	ErrorType          string       `json:"error_type" gorm:"type:varchar(20)"`
+	ResponseModel      string       `json:"response_model" gorm:"type:varchar(100)"`
+	FinishReason       string       `json:"finish_reason" gorm:"type:varchar(50)"`
+	PromptTokens       int          `json:"prompt_tokens"`
+	CompletionTokens   int          `json:"completion_tokens"`
+	TotalTokens        int          `json:"total_tokens"`
+	TimeToFirstTokenMs int64        `json:"time_to_first_token_ms"`
+	LatencyMs          int64        `json:"latency_ms"`
+	Cost               *float64     `json:"cost"`
+	Currency           string       `json:"currency" gorm:"type:varchar(10)"`
+	Actor              string       `json:"actor" gorm:"type:varchar(100)"`
+	CreatedAt          time.Time    `json:"created_at" gorm:"index"`
+}
+
+// Dataset 项目下的评测数据集，每行包含一组模板变量和可选的期望输出
+type Dataset struct {
+	ID          string       `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ProjectID   string       `json:"project_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_dataset_name"`
+	Name        string       `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_dataset_name"`
+	Description string       `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Rows        []DatasetRow `json:"rows,omitempty" gorm:"foreignKey:DatasetID"`
+}
+
+// DatasetRow 数据集中的一行；Input 非空时渲染后的提示词作为系统消息、Input 作为用户消息发送，否则提示词作为用户消息
+type DatasetRow struct {
+	ID             string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	DatasetID      string         `json:"dataset_id" gorm:"type:varchar(36);not null;index"`
+	Position       int            `json:"position"`
+	Variables      VariableValues `json:"variables" gorm:"type:text"`
+	Input          string         `json:"input" gorm:"type:text"`
+	ExpectedOutput string         `json:"expected_output" gorm:"type:text"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Evaluation 使用某个提示词版本对数据集逐行调用模型的批量评测任务
+// Status 为 pending|running|completed|failed|canceled
+type Evaluation struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	ProjectID     string     `json:"project_id" gorm:"type:varchar(36);not null;index"`
+	DatasetID     string     `json:"dataset_id" gorm:"type:varchar(36);not null;index"`
+	PromptID      string     `json:"prompt_id" gorm:"type:varchar(36);not null;index"`
+	PromptVersion string     `json:"prompt_version" gorm:"type:varchar(50)"`
+	Provider      string     `json:"provider" gorm:"type:varchar(50);not null"`
+	Model         string     `json:"model" gorm:"type:varchar(100)"`
+	Temperature   *float64   `json:"temperature"`
+	TopP          *float64   `json:"top_p"`
+	MaxTokens     int        `json:"max_tokens"`
+	Concurrency   int        `json:"concurrency"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	TotalRows     int        `json:"total_rows"`
+	CompletedRows int        `json:"completed_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	TotalTokens   int        `json:"total_tokens"`
+	Cost          *float64   `json:"cost"`
+	Currency      string     `json:"currency" gorm:"type:varchar(10)"`
+	Error         string     `json:"error" gorm:"type:text"`
+	Actor         string     `json:"actor" gorm:"type:varchar(100)"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+}
+
+// EvaluationResult 批量评测中单行的调用结果，Status 为 success|error
+type EvaluationResult struct {
+	ID               string       `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	EvaluationID     string       `json:"evaluation_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_evaluation_row"`
+	RowID            string       `json:"row_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_evaluation_row"`
+	Position         int          `json:"position"`
+	Messages         ChatMessages `json:"messages" gorm:"type:text"`
+	Output           string       `json:"output" gorm:"type:text"`
+	ExpectedOutput   string       `json:"expected_output" gorm:"type:text"`
+	Status           string       `json:"status" gorm:"type:varchar(20);not null"`
+	Error            string       `json:"error" gorm:"type:text"`
+	ErrorType        string       `json:"error_type" gorm:"type:varchar(20)"`
+	FinishReason     string       `json:"finish_reason" gorm:"type:varchar(50)"`
+	PromptTokens     int          `json:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens"`
+	TotalTokens      int          `json:"total_tokens"`
+	LatencyMs        int64        `json:"latency_ms"`
+	Cost             *float64     `json:"cost"`
+	Currency         string       `json:"currency" gorm:"type:varchar(10)"`
+	CreatedAt        time.Time    `json:"created_at"`
+}
+
+// PromptTestCase 提示词的回归测试用例，属于提示词实体，每个新版本创建后都会执行
+// 提示词渲染后作为 system 消息，后接 Messages；Messages 为空时提示词作为 user 消息发送
+type PromptTestCase struct {
+	ID         string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	EntityID   string         `json:"entity_id" gorm:"type:varchar(36);not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(100);not null"`
+	Variables  VariableValues `json:"variables" gorm:"type:text"`
+	Messages   ChatMessages   `json:"messages" gorm:"type:text"`
+	Assertions Assertions     `json:"assertions" gorm:"type:text"` // 期望的子串、正则等
+	Provider   string         `json:"provider" gorm:"type:varchar(50)"`
+	Model      string         `json:"model" gorm:"type:varchar(100)"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// PromptCheckResult 提示词版本回归检查中单个测试用例的结果，Status 为 passed|failed|error
+type PromptCheckResult struct {
+	ID               string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	PromptID         string            `json:"prompt_id" gorm:"type:varchar(36);not null;index"`
+	TestCaseID       string            `json:"test_case_id" gorm:"type:varchar(36);index"`
+	Position         int               `json:"position"` // 测试用例的创建顺序
+	Name             string            `json:"name" gorm:"type:varchar(100)"`
+	Status           string            `json:"status" gorm:"type:varchar(20);not null"`
+	Output           string            `json:"output" gorm:"type:text"`
+	Score            float64           `json:"score"`
+	Assertions       AssertionOutcomes `json:"assertions" gorm:"type:text"`
+	Error            string            `json:"error" gorm:"type:text"`
+	ErrorType        string            `json:"error_type" gorm:"type:varchar(20)"`
+	Provider         string            `json:"provider" gorm:"type:varchar(50)"`
+	Model            string            `json:"model" gorm:"type:varchar(100)"`
+	PromptTokens     int               `json:"prompt_tokens"`
+	CompletionTokens int               `json:"completion_tokens"`
+	TotalTokens      int               `json:"total_tokens"`
+	LatencyMs        int64             `json:"latency_ms"`
+	Cost             *float64          `json:"cost"`
+	Currency         string            `json:"currency" gorm:"type:varchar(10)"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+// PromptReview 提示词提议版本的审批记录，Action 为 submit|comment|approve|reject
+type PromptReview struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	PromptID  string    `json:"prompt_id" gorm:"type:varchar(36);not null;index"`
+	Reviewer  string    `json:"reviewer" gorm:"type:varchar(100);not null"`
+	Action    string    `json:"action" gorm:"type:varchar(20);not null"`
+	Comment   string    `json:"comment" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Setting struct {
 	Key         string    `json:"key" gorm:"primaryKey;type:varchar(50)"`
 	Value       string    `json:"value" gorm:"type:text"`
@@ -71,6 +609,13 @@ func (p *Project) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (e *PromptEntity) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
 func (p *Prompt) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == "" {
 		p.ID = uuid.New().String()
@@ -98,3 +643,119 @@ func (ph *PromptHistory) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (l *PromptLabel) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (lh *PromptLabelHistory) BeforeCreate(tx *gorm.DB) error {
+	if lh.ID == "" {
+		lh.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == "" {
+		k.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (p *CustomProvider) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (r *TestRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (d *Dataset) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (r *DatasetRow) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (e *Evaluation) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (r *EvaluationResult) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (t *PromptTestCase) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (r *PromptCheckResult) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+func (r *PromptReview) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ErrAuditLogAppendOnly 审计日志不允许修改或删除
+var ErrAuditLogAppendOnly = errors.New("audit logs are append-only")
+
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
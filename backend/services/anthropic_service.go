@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicVersion          = "2023-06-01"
+	anthropicDefaultMaxTokens = 4096
+)
+
+type AnthropicProvider struct {
+	BaseModelProvider
+}
+
+func NewAnthropicProvider() *AnthropicProvider {
+	return &AnthropicProvider{
+		BaseModelProvider: BaseModelProvider{
+			defaultModel:  "claude-sonnet-4-5",
+			defaultAPIURL: "https://api.anthropic.com/v1/messages",
+		},
+	}
+}
+
+// AnthropicMessage Messages API 的对话消息，只包含 user 和 assistant 角色
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type AnthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Stream      bool               `json:"stream"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	TopP        *float64           `json:"top_p,omitempty"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type AnthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      AnthropicUsage `json:"usage"`
+}
+
+// AnthropicStreamEvent 流式响应事件，文本增量在 content_block_delta 事件的 delta.text 中
+// message_start 携带模型和输入 tokens，message_delta 携带结束原因和输出 tokens
+type AnthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Model string         `json:"model"`
+		Usage AnthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *AnthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *AnthropicProvider) NormalizeAPIURL(url string) string {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return p.defaultAPIURL
+	}
+
+	if strings.HasSuffix(url, "/messages") {
+		return url
+	}
+
+	url = strings.TrimSuffix(url, "/")
+	if strings.HasSuffix(url, "/v1") {
+		return url + "/messages"
+	}
+
+	return url + "/v1/messages"
+}
+
+// buildRequest 将 OpenAI 格式的消息转换为 Messages API 请求：system 消息合并为顶层 system 字段
+func (p *AnthropicProvider) buildRequest(options ChatOptions, messages []OpenAIMessage, stream bool) AnthropicRequest {
+	if options.Model == "" {
+		options.Model = p.defaultModel
+	}
+	if options.MaxTokens <= 0 {
+		options.MaxTokens = anthropicDefaultMaxTokens
+	}
+
+	var systemParts []string
+	converted := make([]AnthropicMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, msg.Content)
+			continue
+		}
+		role := msg.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		converted = append(converted, AnthropicMessage{Role: role, Content: msg.Content})
+	}
+
+	return AnthropicRequest{
+		Model:       options.Model,
+		System:      strings.Join(systemParts, "\n\n"),
+		Messages:    converted,
+		MaxTokens:   options.MaxTokens,
+		Stream:      stream,
+		Temperature: options.Temperature,
+		TopP:        options.TopP,
+	}
+}
+
+func (p *AnthropicProvider) header(apiKey string) http.Header {
+	header := http.Header{}
+	header.Set("x-api-key", apiKey)
+	header.Set("anthropic-version", anthropicVersion)
+	return header
+}
+
+func (p *AnthropicProvider) CallChat(ctx context.Context, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (*ChatResult, error) {
+	body := p.buildRequest(options, messages, false)
+
+	var resp AnthropicResponse
+	if err := llmClient.PostJSON(ctx, string(ProviderAnthropic), p.NormalizeAPIURL(apiURL), p.header(apiKey), body, &resp); err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	result := &ChatResult{Content: content.String()}
+	result.Model = body.Model
+	if resp.Model != "" {
+		result.Model = resp.Model
+	}
+	result.FinishReason = resp.StopReason
+	result.setUsage(resp.Usage.InputTokens, resp.Usage.OutputTokens, 0)
+	return result, nil
+}
+
+// CallChatStream 事件格式为 "event: <type>" 与 "data: <json>" 成对出现，事件类型在 data 中同样存在，这里只解析 data 行
+func (p *AnthropicProvider) CallChatStream(ctx context.Context, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) (*ChatResult, error) {
+	body := p.buildRequest(options, messages, true)
+
+	result := &ChatResult{}
+	result.Model = body.Model
+	var content strings.Builder
+	var inputTokens, outputTokens int
+	err := llmClient.PostStream(ctx, string(ProviderAnthropic), p.NormalizeAPIURL(apiURL), p.header(apiKey), body, func(data string) (bool, error) {
+		var event AnthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, nil
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message.Model != "" {
+				result.Model = event.Message.Model
+			}
+			inputTokens = event.Message.Usage.InputTokens
+			outputTokens = event.Message.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				content.WriteString(event.Delta.Text)
+				return false, callback(event.Delta.Text)
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				result.FinishReason = event.Delta.StopReason
+			}
+			if event.Usage != nil {
+				outputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			return true, anthropicStreamError(event)
+		case "message_stop":
+			return true, nil
+		}
+		return false, nil
+	})
+	result.Content = content.String()
+	result.setUsage(inputTokens, outputTokens, 0)
+	return result, err
+}
+
+// anthropicStreamError 流式响应中途返回的错误事件，按错误类型归类
+func anthropicStreamError(event AnthropicStreamEvent) error {
+	kind := ErrProviderUpstream
+	switch event.Error.Type {
+	case "authentication_error", "permission_error":
+		kind = ErrProviderAuth
+	case "rate_limit_error":
+		kind = ErrProviderRateLimited
+	case "invalid_request_error":
+		kind = ErrProviderBadRequest
+	}
+	return &ProviderError{
+		Kind:     kind,
+		Provider: string(ProviderAnthropic),
+		Message:  fmt.Sprintf("stream error: %s: %s", event.Error.Type, event.Error.Message),
+	}
+}
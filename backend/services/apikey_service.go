@@ -0,0 +1,26 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// APIKeyPrefix 所有 API Key 的固定前缀，便于识别
+const APIKeyPrefix = "pm_"
+
+// GenerateAPIKey 生成新的 API Key，返回明文、展示用前缀和哈希值
+func GenerateAPIKey() (plain, prefix, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	plain = APIKeyPrefix + hex.EncodeToString(buf)
+	return plain, plain[:len(APIKeyPrefix)+8], HashToken(plain), nil
+}
+
+// HashToken 计算 API Key 或会话令牌的哈希值，数据库中只保存该值
+func HashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"prompt-manager/models"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 断言类型
+const (
+	AssertionEquals      = "equals"
+	AssertionContains    = "contains"
+	AssertionNotContains = "not_contains"
+	AssertionRegex       = "regex"
+	AssertionJSONSchema  = "json_schema"
+	AssertionLength      = "length"
+	AssertionLLMJudge    = "llm_judge"
+)
+
+// defaultJudgeThreshold llm_judge 未指定 threshold 时通过所需的最低得分
+const defaultJudgeThreshold = 0.7
+
+// judgeSystemPrompt 评审模型的系统提示词，要求只输出 JSON
+const judgeSystemPrompt = `你是一名严格、公正的评审员。请根据给定的评分标准评价助手的回复。
+只输出一个 JSON 对象，不要输出其他内容，格式为：{"score": <0 到 10 的整数>, "reason": "<简要说明评分理由>"}`
+
+// AssertionResult 单条断言的结果，Score 在 0 到 1 之间
+type AssertionResult struct {
+	Name    string       `json:"name,omitempty"`
+	Type    string       `json:"type"`
+	Passed  bool         `json:"passed"`
+	Score   float64      `json:"score"`
+	Reason  string       `json:"reason"`
+	Metrics *ChatMetrics `json:"metrics,omitempty"` // llm_judge 评审调用的用量和费用
+}
+
+// AssertionReport 全部断言的结果，Score 为各断言得分按权重的平均值，全部通过时 Passed 为 true
+type AssertionReport struct {
+	Passed  bool              `json:"passed"`
+	Score   float64           `json:"score"`
+	Results []AssertionResult `json:"results"`
+}
+
+// JudgeFunc 调用 llm_judge 的评审模型，服务商凭证由调用方解析
+type JudgeFunc func(ctx context.Context, assertion models.Assertion, messages []OpenAIMessage) (*ChatResult, error)
+
+type AssertionService struct{}
+
+func NewAssertionService() *AssertionService {
+	return &AssertionService{}
+}
+
+// Validate 校验断言定义本身是否合法
+func (s *AssertionService) Validate(assertions models.Assertions) error {
+	var details []string
+	for i, a := range assertions {
+		label := fmt.Sprintf("assertion %d (%s)", i+1, a.Type)
+		if a.Weight < 0 {
+			details = append(details, label+": weight must not be negative")
+		}
+		switch a.Type {
+		case AssertionEquals, AssertionContains, AssertionNotContains:
+			if a.Value == "" {
+				details = append(details, label+": value is required")
+			}
+		case AssertionRegex:
+			if _, err := compileAssertionRegex(a); err != nil {
+				details = append(details, fmt.Sprintf("%s: invalid regex: %v", label, err))
+			}
+		case AssertionJSONSchema:
+			if len(a.Schema) > 0 {
+				var schema map[string]any
+				if err := json.Unmarshal(a.Schema, &schema); err != nil {
+					details = append(details, label+": schema must be a JSON object")
+				}
+			}
+		case AssertionLength:
+			if a.Min == nil && a.Max == nil {
+				details = append(details, label+": min or max is required")
+			} else if a.Min != nil && a.Max != nil && *a.Min > *a.Max {
+				details = append(details, label+": min must not exceed max")
+			}
+		case AssertionLLMJudge:
+			if strings.TrimSpace(a.Rubric) == "" {
+				details = append(details, label+": rubric is required")
+			}
+			if a.Threshold != nil && (*a.Threshold < 0 || *a.Threshold > 1) {
+				details = append(details, label+": threshold must be between 0 and 1")
+			}
+		default:
+			details = append(details, fmt.Sprintf("assertion %d: unsupported type %q", i+1, a.Type))
+		}
+	}
+	if len(details) > 0 {
+		return &AssertionError{Details: details}
+	}
+	return nil
+}
+
+// AssertionError 断言定义校验错误
+type AssertionError struct {
+	Details []string
+}
+
+func (e *AssertionError) Error() string {
+	return "invalid assertions: " + strings.Join(e.Details, "; ")
+}
+
+// Evaluate 依次执行断言，messages 为发送给被测模型的消息，供 llm_judge 参考；judge 为空时 llm_judge 断言记为失败
+func (s *AssertionService) Evaluate(ctx context.Context, assertions models.Assertions, messages []OpenAIMessage, output string, judge JudgeFunc) *AssertionReport {
+	report := &AssertionReport{Passed: true, Results: make([]AssertionResult, 0, len(assertions))}
+
+	var weighted, totalWeight float64
+	for _, a := range assertions {
+		result := AssertionResult{Name: a.Name, Type: a.Type}
+		switch a.Type {
+		case AssertionLLMJudge:
+			s.judge(ctx, a, messages, output, judge, &result)
+		default:
+			result.Passed, result.Reason = s.check(a, output)
+			if result.Passed {
+				result.Score = 1
+			}
+		}
+
+		weight := a.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		weighted += result.Score * weight
+		totalWeight += weight
+		if !result.Passed {
+			report.Passed = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	if totalWeight > 0 {
+		report.Score = math.Round(weighted/totalWeight*1000) / 1000
+	}
+	return report
+}
+
+// check 执行确定性断言，返回是否通过及原因
+func (s *AssertionService) check(a models.Assertion, output string) (bool, string) {
+	compare := func(text string) string {
+		if a.IgnoreCase {
+			return strings.ToLower(text)
+		}
+		return text
+	}
+
+	switch a.Type {
+	case AssertionEquals:
+		if compare(strings.TrimSpace(output)) == compare(strings.TrimSpace(a.Value)) {
+			return true, "output equals the expected value"
+		}
+		return false, "output does not equal the expected value"
+	case AssertionContains:
+		if strings.Contains(compare(output), compare(a.Value)) {
+			return true, fmt.Sprintf("output contains %q", a.Value)
+		}
+		return false, fmt.Sprintf("output does not contain %q", a.Value)
+	case AssertionNotContains:
+		if strings.Contains(compare(output), compare(a.Value)) {
+			return false, fmt.Sprintf("output contains %q", a.Value)
+		}
+		return true, fmt.Sprintf("output does not contain %q", a.Value)
+	case AssertionRegex:
+		re, err := compileAssertionRegex(a)
+		if err != nil {
+			return false, "invalid regex: " + err.Error()
+		}
+		if re.MatchString(output) {
+			return true, fmt.Sprintf("output matches %s", a.Value)
+		}
+		return false, fmt.Sprintf("output does not match %s", a.Value)
+	case AssertionJSONSchema:
+		var value any
+		if err := json.Unmarshal([]byte(extractJSON(output)), &value); err != nil {
+			return false, "output is not valid JSON: " + err.Error()
+		}
+		if len(a.Schema) == 0 {
+			return true, "output is valid JSON"
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(a.Schema, &schema); err != nil {
+			return false, "invalid schema: " + err.Error()
+		}
+		if errs := validateJSONSchema(schema, value, "$"); len(errs) > 0 {
+			return false, strings.Join(errs, "; ")
+		}
+		return true, "output matches the JSON schema"
+	case AssertionLength:
+		length := utf8.RuneCountInString(strings.TrimSpace(output))
+		if a.Min != nil && length < *a.Min {
+			return false, fmt.Sprintf("output has %d characters, fewer than %d", length, *a.Min)
+		}
+		if a.Max != nil && length > *a.Max {
+			return false, fmt.Sprintf("output has %d characters, more than %d", length, *a.Max)
+		}
+		return true, fmt.Sprintf("output has %d characters", length)
+	default:
+		return false, fmt.Sprintf("unsupported assertion type %q", a.Type)
+	}
+}
+
+// judge 调用评审模型按评分标准打分，0-10 分换算为 0-1
+func (s *AssertionService) judge(ctx context.Context, a models.Assertion, messages []OpenAIMessage, output string, judge JudgeFunc, result *AssertionResult) {
+	if judge == nil {
+		result.Reason = "no judge model available"
+		return
+	}
+
+	var conversation strings.Builder
+	for _, message := range messages {
+		fmt.Fprintf(&conversation, "[%s]\n%s\n\n", message.Role, message.Content)
+	}
+	prompt := fmt.Sprintf("## 评分标准\n%s\n\n## 对话\n%s## 待评价的助手回复\n%s", a.Rubric, conversation.String(), output)
+
+	chat, err := judge(ctx, a, []OpenAIMessage{
+		{Role: "system", Content: judgeSystemPrompt},
+		{Role: "user", Content: prompt},
+	})
+	if err != nil {
+		result.Reason = "judge call failed: " + err.Error()
+		return
+	}
+	result.Metrics = &chat.ChatMetrics
+
+	var verdict struct {
+		Score  *float64 `json:"score"`
+		Reason string   `json:"reason"`
+	}
+	content := chat.Content
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(content), &verdict); err != nil || verdict.Score == nil {
+		result.Reason = "judge returned an invalid verdict: " + strings.TrimSpace(chat.Content)
+		return
+	}
+
+	result.Score = math.Max(0, math.Min(*verdict.Score, 10)) / 10
+	result.Reason = verdict.Reason
+	threshold := defaultJudgeThreshold
+	if a.Threshold != nil {
+		threshold = *a.Threshold
+	}
+	result.Passed = result.Score >= threshold
+}
+
+func compileAssertionRegex(a models.Assertion) (*regexp.Regexp, error) {
+	if a.Value == "" {
+		return nil, errors.New("value is required")
+	}
+	pattern := a.Value
+	if a.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// extractJSON 去掉模型输出中常见的 ```json 代码块包裹，返回其中的 JSON 文本
+func extractJSON(output string) string {
+	text := strings.TrimSpace(output)
+	if rest, ok := strings.CutPrefix(text, "```"); ok {
+		if newline := strings.IndexByte(rest, '\n'); newline >= 0 {
+			rest = rest[newline+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	return text
+}
+
+// validateJSONSchema 按 JSON Schema 的常用子集校验 value，返回所有不符合项
+// 支持 type、enum、properties、required、additionalProperties(false)、items、
+// minLength/maxLength、minimum/maximum、minItems/maxItems
+func validateJSONSchema(schema map[string]any, value any, path string) []string {
+	var errs []string
+
+	if expected, ok := schema["type"]; ok && !matchesSchemaType(expected, value) {
+		return []string{fmt.Sprintf("%s: expected %v, got %s", path, expected, jsonTypeName(value))}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: value is not one of the allowed values", path))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				key, _ := name.(string)
+				if _, exists := v[key]; !exists {
+					errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, key))
+				}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := v[key]
+			sub, ok := properties[key].(map[string]any)
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %q", path, key))
+				}
+				continue
+			}
+			errs = append(errs, validateJSONSchema(sub, item, path+"."+key)...)
+		}
+	case []any:
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < min {
+			errs = append(errs, fmt.Sprintf("%s: expected at least %v items, got %d", path, min, len(v)))
+		}
+		if max, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > max {
+			errs = append(errs, fmt.Sprintf("%s: expected at most %v items, got %d", path, max, len(v)))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateJSONSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if min, ok := schemaNumber(schema, "minLength"); ok && length < min {
+			errs = append(errs, fmt.Sprintf("%s: expected at least %v characters", path, min))
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && length > max {
+			errs = append(errs, fmt.Sprintf("%s: expected at most %v characters", path, max))
+		}
+	case float64:
+		if min, ok := schemaNumber(schema, "minimum"); ok && v < min {
+			errs = append(errs, fmt.Sprintf("%s: %v is less than minimum %v", path, v, min))
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && v > max {
+			errs = append(errs, fmt.Sprintf("%s: %v is greater than maximum %v", path, v, max))
+		}
+	}
+	return errs
+}
+
+// matchesSchemaType type 可以是单个类型名或类型名数组
+func matchesSchemaType(expected any, value any) bool {
+	switch t := expected.(type) {
+	case string:
+		actual := jsonTypeName(value)
+		return t == actual || (t == "number" && actual == "integer")
+	case []any:
+		for _, item := range t {
+			if matchesSchemaType(item, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	n, ok := schema[key].(float64)
+	return n, ok
+}
+
+func jsonEqual(a, b any) bool {
+	left, err1 := json.Marshal(a)
+	right, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(left) == string(right)
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"prompt-manager/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 模型服务商调用失败的分类，可通过 errors.Is 判断
+var (
+	ErrProviderAuth        = errors.New("provider authentication failed")
+	ErrProviderRateLimited = errors.New("provider rate limit exceeded")
+	ErrProviderBadRequest  = errors.New("provider rejected the request")
+	ErrProviderUpstream    = errors.New("provider upstream failure")
+	ErrProviderTimeout     = errors.New("provider request timed out")
+)
+
+// maxErrorBodySize 错误响应体最多读取的字节数
+const maxErrorBodySize = 64 * 1024
+
+// errRequestTimeout 作为超时取消请求时的 cause，用于区分调用方主动取消
+var errRequestTimeout = errors.New("request timeout")
+
+// ProviderError 模型服务商调用错误，Kind 为上面的分类之一
+type ProviderError struct {
+	Kind       error
+	Provider   string
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("%s API request failed with status %d: %s", e.Provider, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s API request failed: %s", e.Provider, e.Message)
+}
+
+func (e *ProviderError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// retryable 限流、上游故障和网络错误可以重试
+func (e *ProviderError) retryable() bool {
+	return e.Kind == ErrProviderRateLimited || e.Kind == ErrProviderUpstream
+}
+
+// newStatusError 根据响应状态码构造错误
+func newStatusError(provider string, resp *http.Response, body []byte) *ProviderError {
+	err := &ProviderError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		err.Kind = ErrProviderAuth
+	case resp.StatusCode == http.StatusTooManyRequests:
+		err.Kind = ErrProviderRateLimited
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		err.Kind = ErrProviderUpstream
+	default:
+		err.Kind = ErrProviderBadRequest
+	}
+	return err
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期形式的 Retry-After
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// LLMClient 所有模型服务商共用的 HTTP 客户端
+type LLMClient struct {
+	httpClient     *http.Client
+	timeout        time.Duration
+	timeouts       map[string]time.Duration
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+}
+
+func NewLLMClient(cfg config.ProvidersConfig) *LLMClient {
+	return &LLMClient{
+		// 超时由每次请求的 context 控制，流式响应不能使用客户端级别的总超时
+		httpClient:     &http.Client{},
+		timeout:        cfg.Timeout,
+		timeouts:       cfg.Timeouts,
+		maxRetries:     cfg.MaxRetries,
+		retryBaseDelay: cfg.RetryBaseDelay,
+		retryMaxDelay:  cfg.RetryMaxDelay,
+	}
+}
+
+var llmClient = NewLLMClient(config.DefaultProvidersConfig())
+
+// InitLLMClient 按配置初始化共用客户端，需在处理请求前调用
+func InitLLMClient(cfg config.ProvidersConfig) {
+	llmClient = NewLLMClient(cfg)
+}
+
+func (c *LLMClient) timeoutFor(provider string) time.Duration {
+	if timeout, ok := c.timeouts[provider]; ok && timeout > 0 {
+		return timeout
+	}
+	return c.timeout
+}
+
+// retryDelay 优先使用 Retry-After，否则按指数退避并加入随机抖动，均不超过 retryMaxDelay
+func (c *LLMClient) retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, c.retryMaxDelay)
+	}
+	delay := c.retryBaseDelay << attempt
+	if delay <= 0 || delay > c.retryMaxDelay {
+		delay = c.retryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// llmResponse 状态码为 200 的响应，关闭时释放请求上下文
+type llmResponse struct {
+	*http.Response
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	timer  *time.Timer
+}
+
+func (r *llmResponse) Close() {
+	r.timer.Stop()
+	r.Body.Close()
+	r.cancel(nil)
+}
+
+// post 发送请求，遇到可重试的错误时按退避策略重试
+func (c *LLMClient) post(ctx context.Context, provider, url string, header http.Header, body any, stream bool) (*llmResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, provider, url, header, data, stream)
+		if err == nil {
+			return resp, nil
+		}
+
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) || !providerErr.retryable() || attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		timer := time.NewTimer(c.retryDelay(attempt, providerErr.RetryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send 发送单次请求；非流式请求的超时持续到响应体关闭，流式请求收到响应头后即停止计时
+func (c *LLMClient) send(ctx context.Context, provider, url string, header http.Header, data []byte, stream bool) (*llmResponse, error) {
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(c.timeoutFor(provider), func() { cancel(errRequestTimeout) })
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		timer.Stop()
+		cancel(nil)
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		timer.Stop()
+		err = transportError(ctx, reqCtx, provider, err)
+		cancel(nil)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		resp.Body.Close()
+		timer.Stop()
+		cancel(nil)
+		return nil, newStatusError(provider, resp, body)
+	}
+
+	if stream {
+		timer.Stop()
+	}
+	return &llmResponse{Response: resp, ctx: reqCtx, cancel: cancel, timer: timer}, nil
+}
+
+// transportError 区分调用方取消、请求超时和网络错误
+func transportError(ctx, reqCtx context.Context, provider string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if context.Cause(reqCtx) == errRequestTimeout {
+		return &ProviderError{Kind: ErrProviderTimeout, Provider: provider, Message: "request timed out", Err: err}
+	}
+	return &ProviderError{Kind: ErrProviderUpstream, Provider: provider, Message: err.Error(), Err: err}
+}
+
+// PostJSON 发送非流式请求并将响应解析到 out
+func (c *LLMClient) PostJSON(ctx context.Context, provider, url string, header http.Header, body any, out any) error {
+	resp, err := c.post(ctx, provider, url, header, body, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return transportError(ctx, resp.ctx, provider, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return &ProviderError{Kind: ErrProviderUpstream, Provider: provider, Message: "invalid response: " + err.Error(), Err: err}
+	}
+	return nil
+}
+
+// PostStream 发送流式请求，按行读取 SSE 响应并将每个 data 字段交给 onData，onData 返回 true 时结束读取
+func (c *LLMClient) PostStream(ctx context.Context, provider, url string, header http.Header, body any, onData func(data string) (bool, error)) error {
+	resp, err := c.post(ctx, provider, url, header, body, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return transportError(ctx, resp.ctx, provider, err)
+		}
+
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		done, err := onData(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
@@ -1,17 +1,25 @@
 package services
 
+import "context"
+
 type OpenAIMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 }
 
 type OpenAIRequest struct {
-	Model       string          `json:"model"`
-	Messages    []OpenAIMessage `json:"messages"`
-	Stream      bool            `json:"stream"`
-	Temperature *float64        `json:"temperature,omitempty"`
-	TopP        *float64        `json:"top_p,omitempty"`
-	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Model         string               `json:"model"`
+	Messages      []OpenAIMessage      `json:"messages"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+}
+
+// OpenAIStreamOptions 开启 include_usage 后流式响应的最后一个数据块携带 usage
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type ChatOptions struct {
@@ -21,26 +29,70 @@ type ChatOptions struct {
 	MaxTokens   int
 }
 
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
 type OpenAIResponse struct {
+	Model   string `json:"model"`
 	Choices []struct {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"`
 }
 
+// OpenAIStreamResponse 流式数据块；部分服务商（如 Kimi）将 usage 放在 choices 中
 type OpenAIStreamResponse struct {
+	Model   string `json:"model"`
 	Choices []struct {
 		Delta struct {
 			Content string `json:"content"`
 		} `json:"delta"`
-		FinishReason string `json:"finish_reason"`
+		FinishReason string       `json:"finish_reason"`
+		Usage        *OpenAIUsage `json:"usage"`
 	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"`
 }
 
+// ChatResult 一次模型调用的结果
+type ChatResult struct {
+	Content string `json:"-"`
+	ChatMetrics
+}
+
+// ChatMetrics 模型调用的用量、耗时和费用；服务商未返回用量或价格表中没有该模型时不计算费用
+type ChatMetrics struct {
+	Provider           string   `json:"provider"`
+	Model              string   `json:"model"`
+	FinishReason       string   `json:"finish_reason"`
+	PromptTokens       int      `json:"prompt_tokens"`
+	CompletionTokens   int      `json:"completion_tokens"`
+	TotalTokens        int      `json:"total_tokens"`
+	TimeToFirstTokenMs int64    `json:"time_to_first_token_ms,omitempty"`
+	LatencyMs          int64    `json:"latency_ms"`
+	Cost               *float64 `json:"cost,omitempty"`
+	Currency           string   `json:"currency,omitempty"`
+}
+
+// setUsage 记录用量，服务商未返回 total_tokens 时自行求和
+func (r *ChatResult) setUsage(promptTokens, completionTokens, totalTokens int) {
+	r.PromptTokens = promptTokens
+	r.CompletionTokens = completionTokens
+	r.TotalTokens = totalTokens
+	if r.TotalTokens == 0 {
+		r.TotalTokens = promptTokens + completionTokens
+	}
+}
+
+// ModelProvider 模型服务商，ctx 取消（如浏览器断开 SSE 连接）时中止上游请求
 type ModelProvider interface {
-	CallChat(apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (string, error)
-	CallChatStream(apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) error
+	CallChat(ctx context.Context, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (*ChatResult, error)
+	CallChatStream(ctx context.Context, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) (*ChatResult, error)
 	GetDefaultModel() string
 	GetDefaultAPIURL() string
 	NormalizeAPIURL(url string) string
@@ -60,9 +112,5 @@ func (p *BaseModelProvider) GetDefaultAPIURL() string {
 }
 
 func (p *BaseModelProvider) NormalizeAPIURL(url string) string {
-	url = normalizeAPIURL(url)
-	if url == "" {
-		return p.defaultAPIURL
-	}
-	return url
+	return normalizeChatURL(url, p.defaultAPIURL)
 }
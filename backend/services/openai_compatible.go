@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// 自定义服务商的鉴权方式
+const (
+	AuthSchemeBearer = "bearer"
+	AuthSchemeHeader = "header"
+	AuthSchemeNone   = "none"
+)
+
+// OpenAICompatibleConfig OpenAI 兼容服务商的配置，内置服务商和自定义服务商共用
+type OpenAICompatibleConfig struct {
+	Name         string
+	BaseURL      string
+	AuthScheme   string
+	AuthHeader   string
+	DefaultModel string
+	Models       []string
+	ExtraHeaders map[string]string
+	// StreamUsage 流式请求时发送 stream_options.include_usage，不支持该参数的服务商需关闭
+	StreamUsage bool
+}
+
+// OpenAICompatibleProvider 使用 OpenAI Chat Completions 协议的服务商
+// 包括阿里云、DeepSeek、豆包、GLM、Kimi、OpenAI，以及通过配置接入的 vLLM、Ollama、LM Studio、OneAPI 等
+type OpenAICompatibleProvider struct {
+	BaseModelProvider
+	config OpenAICompatibleConfig
+}
+
+func NewOpenAICompatibleProvider(config OpenAICompatibleConfig) *OpenAICompatibleProvider {
+	p := &OpenAICompatibleProvider{config: config}
+	p.defaultModel = config.DefaultModel
+	p.defaultAPIURL = normalizeChatURL(config.BaseURL, "")
+	return p
+}
+
+// Models 服务商可选的模型列表
+func (p *OpenAICompatibleProvider) Models() []string {
+	return p.config.Models
+}
+
+// RequiresAPIKey 鉴权方式为 none 时无需配置 API Key
+func (p *OpenAICompatibleProvider) RequiresAPIKey() bool {
+	return p.config.AuthScheme != AuthSchemeNone
+}
+
+func (p *OpenAICompatibleProvider) NormalizeAPIURL(url string) string {
+	return normalizeChatURL(url, p.defaultAPIURL)
+}
+
+// normalizeChatURL 补全 /chat/completions 路径，地址为空时使用默认地址
+func normalizeChatURL(url, defaultURL string) string {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return defaultURL
+	}
+
+	if strings.HasSuffix(url, "/chat/completions") {
+		return url
+	}
+
+	return strings.TrimSuffix(url, "/") + "/chat/completions"
+}
+
+func (p *OpenAICompatibleProvider) header(apiKey string) http.Header {
+	header := http.Header{}
+	for key, value := range p.config.ExtraHeaders {
+		header.Set(key, value)
+	}
+	switch p.config.AuthScheme {
+	case AuthSchemeNone:
+	case AuthSchemeHeader:
+		header.Set(p.config.AuthHeader, apiKey)
+	default:
+		header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return header
+}
+
+func (p *OpenAICompatibleProvider) buildRequest(options ChatOptions, messages []OpenAIMessage, stream bool) OpenAIRequest {
+	if options.Model == "" {
+		options.Model = p.defaultModel
+	}
+
+	req := OpenAIRequest{
+		Model:       options.Model,
+		Messages:    messages,
+		Stream:      stream,
+		Temperature: options.Temperature,
+		TopP:        options.TopP,
+		MaxTokens:   options.MaxTokens,
+	}
+	if stream && p.config.StreamUsage {
+		req.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+	return req
+}
+
+func (p *OpenAICompatibleProvider) CallChat(ctx context.Context, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (*ChatResult, error) {
+	body := p.buildRequest(options, messages, false)
+
+	var resp OpenAIResponse
+	if err := llmClient.PostJSON(ctx, p.config.Name, p.NormalizeAPIURL(apiURL), p.header(apiKey), body, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &ChatResult{}
+	result.Model = body.Model
+	if resp.Model != "" {
+		result.Model = resp.Model
+	}
+	if len(resp.Choices) > 0 {
+		result.Content = resp.Choices[0].Message.Content
+		result.FinishReason = resp.Choices[0].FinishReason
+	}
+	if resp.Usage != nil {
+		result.setUsage(resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+	}
+	return result, nil
+}
+
+func (p *OpenAICompatibleProvider) CallChatStream(ctx context.Context, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) (*ChatResult, error) {
+	body := p.buildRequest(options, messages, true)
+
+	result := &ChatResult{}
+	result.Model = body.Model
+	var content strings.Builder
+	err := llmClient.PostStream(ctx, p.config.Name, p.NormalizeAPIURL(apiURL), p.header(apiKey), body, func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk OpenAIStreamResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, nil
+		}
+
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		usage := chunk.Usage
+		if len(chunk.Choices) > 0 {
+			choice := chunk.Choices[0]
+			if choice.FinishReason != "" {
+				result.FinishReason = choice.FinishReason
+			}
+			if usage == nil {
+				usage = choice.Usage
+			}
+		}
+		if usage != nil {
+			result.setUsage(usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+		}
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			return false, callback(chunk.Choices[0].Delta.Content)
+		}
+		return false, nil
+	})
+	result.Content = content.String()
+	return result, err
+}
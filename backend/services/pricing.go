@@ -0,0 +1,54 @@
+package services
+
+import (
+	"prompt-manager/config"
+	"sync"
+)
+
+var (
+	pricing   config.PricingConfig
+	pricingMu sync.RWMutex
+)
+
+// InitPricing 设置模型价格表
+func InitPricing(cfg config.PricingConfig) {
+	pricingMu.Lock()
+	defer pricingMu.Unlock()
+	pricing = cfg
+}
+
+// LookupPrice 查找模型价格，优先匹配 "服务商/模型名称"
+func LookupPrice(providerType ProviderType, model string) (config.ModelPrice, bool) {
+	pricingMu.RLock()
+	defer pricingMu.RUnlock()
+
+	price, ok := pricing.Models[string(providerType)+"/"+model]
+	if !ok {
+		price, ok = pricing.Models[model]
+	}
+	if !ok {
+		return config.ModelPrice{}, false
+	}
+	if price.Currency == "" {
+		price.Currency = pricing.Currency
+	}
+	return price, true
+}
+
+// applyCost 按价格表计算费用，服务商未返回用量或没有价格时不设置
+// 优先按请求的模型名称查找价格，服务商返回的模型名称常带日期后缀（如 gpt-4o-mini-2024-07-18），仅作为后备
+func applyCost(providerType ProviderType, requestedModel string, metrics *ChatMetrics) {
+	if metrics.TotalTokens == 0 {
+		return
+	}
+	price, ok := LookupPrice(providerType, requestedModel)
+	if !ok && metrics.Model != requestedModel {
+		price, ok = LookupPrice(providerType, metrics.Model)
+	}
+	if !ok {
+		return
+	}
+	cost := (float64(metrics.PromptTokens)*price.Input + float64(metrics.CompletionTokens)*price.Output) / 1e6
+	metrics.Cost = &cost
+	metrics.Currency = price.Currency
+}
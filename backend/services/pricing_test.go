@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"prompt-manager/config"
+	"testing"
+)
+
+// 服务商返回带日期后缀的模型名称时，仍按请求的模型名称计费
+func TestCallModelPricesRequestedModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"gpt-4o-mini-2024-07-18","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],`+
+			`"usage":{"prompt_tokens":1000000,"completion_tokens":2000000,"total_tokens":3000000}}`)
+	}))
+	defer server.Close()
+
+	InitLLMClient(config.DefaultProvidersConfig())
+	InitPricing(config.PricingConfig{
+		Currency: "USD",
+		Models:   map[string]config.ModelPrice{"gpt-4o-mini": {Input: 0.15, Output: 0.6}},
+	})
+	defer InitPricing(config.PricingConfig{})
+
+	for _, model := range []string{"gpt-4o-mini", ""} {
+		result, err := CallModel(context.Background(), ProviderOpenAI, "sk-test", server.URL, ChatOptions{Model: model},
+			[]OpenAIMessage{{Role: "user", Content: "hello"}})
+		if err != nil {
+			t.Fatalf("model %q: %v", model, err)
+		}
+		if result.Model != "gpt-4o-mini-2024-07-18" {
+			t.Errorf("model %q: result model = %q, want the id returned by the provider", model, result.Model)
+		}
+		if result.Cost == nil || *result.Cost != 1.35 || result.Currency != "USD" {
+			t.Errorf("model %q: cost = %v %q, want 1.35 USD", model, result.Cost, result.Currency)
+		}
+	}
+}
+
+// 请求的模型没有价格时，按服务商返回的模型名称计费
+func TestApplyCostFallsBackToResponseModel(t *testing.T) {
+	InitPricing(config.PricingConfig{
+		Currency: "CNY",
+		Models:   map[string]config.ModelPrice{"deepseek/deepseek-chat-v3": {Input: 2, Output: 8}},
+	})
+	defer InitPricing(config.PricingConfig{})
+
+	metrics := ChatMetrics{Model: "deepseek-chat-v3", PromptTokens: 500000, CompletionTokens: 250000, TotalTokens: 750000}
+	applyCost(ProviderDeepSeek, "deepseek-chat", &metrics)
+	if metrics.Cost == nil || *metrics.Cost != 3 || metrics.Currency != "CNY" {
+		t.Errorf("cost = %v %q, want 3 CNY", metrics.Cost, metrics.Currency)
+	}
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
 type ProviderType string
@@ -13,50 +16,47 @@ const (
 	ProviderDoubao  ProviderType = "doubao"
 	ProviderGLM     ProviderType = "glm"
 	ProviderKimi    ProviderType = "kimi"
+	ProviderOpenAI    ProviderType = "openai"
+	ProviderAnthropic ProviderType = "anthropic"
 )
 
-type AliyunProvider struct {
-	BaseModelProvider
-}
-
-func NewAliyunProvider() *AliyunProvider {
-	return &AliyunProvider{
-		BaseModelProvider: BaseModelProvider{
-			defaultModel: "qwen-turbo",
-			defaultAPIURL: "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions",
-		},
-	}
-}
-
-func (p *AliyunProvider) NormalizeAPIURL(url string) string {
-	return normalizeAPIURL(url)
-}
-
-func (p *AliyunProvider) CallChat(apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (string, error) {
-	return CallAliyunChat(apiKey, apiURL, options, messages)
-}
-
-func (p *AliyunProvider) CallChatStream(apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) error {
-	return CallAliyunChatStream(apiKey, apiURL, options, messages, callback)
+// builtinOpenAICompatible 使用 OpenAI 兼容协议的内置服务商
+var builtinOpenAICompatible = []OpenAICompatibleConfig{
+	{Name: string(ProviderAliyun), BaseURL: "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions", DefaultModel: "qwen-turbo", StreamUsage: true},
+	{Name: string(ProviderDeepSeek), BaseURL: "https://api.deepseek.com/v1/chat/completions", DefaultModel: "deepseek-chat", StreamUsage: true},
+	{Name: string(ProviderDoubao), BaseURL: "https://ark.cn-beijing.volces.com/api/v3/chat/completions", DefaultModel: "doubao-pro-4k", StreamUsage: true},
+	// GLM 和 Kimi 在流式响应的最后一个数据块中默认返回 usage
+	{Name: string(ProviderGLM), BaseURL: "https://open.bigmodel.cn/api/paas/v4/chat/completions", DefaultModel: "glm-4"},
+	{Name: string(ProviderKimi), BaseURL: "https://api.moonshot.cn/v1/chat/completions", DefaultModel: "moonshot-v1-8k"},
+	{Name: string(ProviderOpenAI), BaseURL: "https://api.openai.com/v1/chat/completions", DefaultModel: "gpt-4o-mini", StreamUsage: true},
 }
 
 var (
 	providers     map[ProviderType]ModelProvider
 	providersOnce sync.Once
+	providersMu   sync.RWMutex
+	builtinTypes  map[ProviderType]bool
 )
 
 func initProviders() {
 	providers = make(map[ProviderType]ModelProvider)
-	providers[ProviderAliyun] = NewAliyunProvider()
-	providers[ProviderDeepSeek] = NewDeepSeekProvider()
-	providers[ProviderDoubao] = NewDoubaoProvider()
-	providers[ProviderGLM] = NewGLMProvider()
-	providers[ProviderKimi] = NewKimiProvider()
+	for _, config := range builtinOpenAICompatible {
+		providers[ProviderType(config.Name)] = NewOpenAICompatibleProvider(config)
+	}
+	providers[ProviderAnthropic] = NewAnthropicProvider()
+
+	builtinTypes = make(map[ProviderType]bool, len(providers))
+	for t := range providers {
+		builtinTypes[t] = true
+	}
 }
 
 func GetProvider(providerType ProviderType) (ModelProvider, error) {
 	providersOnce.Do(initProviders)
 
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
 	provider, exists := providers[providerType]
 	if !exists {
 		return nil, fmt.Errorf("provider %s not found", providerType)
@@ -67,35 +67,101 @@ func GetProvider(providerType ProviderType) (ModelProvider, error) {
 
 func RegisterProvider(providerType ProviderType, provider ModelProvider) {
 	providersOnce.Do(initProviders)
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
 	providers[providerType] = provider
 }
 
+// UnregisterProvider 移除运行时注册的服务商，内置服务商不可移除
+func UnregisterProvider(providerType ProviderType) {
+	providersOnce.Do(initProviders)
+	if builtinTypes[providerType] {
+		return
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	delete(providers, providerType)
+}
+
+// IsBuiltinProvider 是否为内置服务商
+func IsBuiltinProvider(providerType ProviderType) bool {
+	providersOnce.Do(initProviders)
+	return builtinTypes[providerType]
+}
+
+// ProviderRequiresAPIKey 服务商是否需要配置 API Key，未实现 RequiresAPIKey 的服务商均需要
+func ProviderRequiresAPIKey(provider ModelProvider) bool {
+	if p, ok := provider.(interface{ RequiresAPIKey() bool }); ok {
+		return p.RequiresAPIKey()
+	}
+	return true
+}
+
 func GetSupportedProviders() []ProviderType {
 	providersOnce.Do(initProviders)
-	
+
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
 	types := make([]ProviderType, 0, len(providers))
 	for t := range providers {
 		types = append(types, t)
 	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
 	return types
 }
 
-func CallModel(providerType ProviderType, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (string, error) {
+// CallModel 调用模型并记录用量、耗时和费用
+func CallModel(ctx context.Context, providerType ProviderType, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage) (*ChatResult, error) {
 	provider, err := GetProvider(providerType)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return provider.CallChat(apiKey, apiURL, options, messages)
+	start := time.Now()
+	result, err := provider.CallChat(ctx, apiKey, apiURL, options, messages)
+	if err != nil {
+		return nil, err
+	}
+	result.Provider = string(providerType)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	applyCost(providerType, requestedModel(provider, options), &result.ChatMetrics)
+	return result, nil
 }
 
-func CallModelStream(providerType ProviderType, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) error {
+// CallModelStream 流式调用模型，额外记录首个 token 的耗时；调用中途失败时返回已收到的部分结果
+func CallModelStream(ctx context.Context, providerType ProviderType, apiKey, apiURL string, options ChatOptions, messages []OpenAIMessage, callback func(string) error) (*ChatResult, error) {
 	provider, err := GetProvider(providerType)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return provider.CallChatStream(apiKey, apiURL, options, messages, callback)
+	start := time.Now()
+	var firstToken time.Duration
+	result, err := provider.CallChatStream(ctx, apiKey, apiURL, options, messages, func(text string) error {
+		if firstToken == 0 {
+			firstToken = time.Since(start)
+		}
+		return callback(text)
+	})
+	if result == nil {
+		return nil, err
+	}
+	result.Provider = string(providerType)
+	result.TimeToFirstTokenMs = firstToken.Milliseconds()
+	result.LatencyMs = time.Since(start).Milliseconds()
+	applyCost(providerType, requestedModel(provider, options), &result.ChatMetrics)
+	return result, err
+}
+
+// requestedModel 本次调用请求的模型名称，未指定时为服务商的默认模型
+func requestedModel(provider ModelProvider, options ChatOptions) string {
+	if options.Model != "" {
+		return options.Model
+	}
+	return provider.GetDefaultModel()
 }
 
 type ModelConfig struct {
@@ -117,8 +183,13 @@ func GetProviderSettingsKey(providerType ProviderType) string {
 		return "glm_api_key"
 	case ProviderKimi:
 		return "kimi_api_key"
+	case ProviderOpenAI:
+		return "openai_api_key"
+	case ProviderAnthropic:
+		return "anthropic_api_key"
 	default:
-		return ""
+		// 自定义服务商按名称派生设置项
+		return string(providerType) + "_api_key"
 	}
 }
 
@@ -134,8 +205,12 @@ func GetProviderURLKey(providerType ProviderType) string {
 		return "glm_api_url"
 	case ProviderKimi:
 		return "kimi_api_url"
+	case ProviderOpenAI:
+		return "openai_api_url"
+	case ProviderAnthropic:
+		return "anthropic_api_url"
 	default:
-		return ""
+		return string(providerType) + "_api_url"
 	}
 }
 
@@ -151,7 +226,11 @@ func GetProviderModelKey(providerType ProviderType) string {
 		return "glm_model"
 	case ProviderKimi:
 		return "kimi_model"
+	case ProviderOpenAI:
+		return "openai_model"
+	case ProviderAnthropic:
+		return "anthropic_model"
 	default:
-		return ""
+		return string(providerType) + "_model"
 	}
 }
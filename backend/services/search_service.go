@@ -0,0 +1,108 @@
+package services
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms 单次搜索最多使用的关键词数量
+const maxSearchTerms = 8
+
+type SearchService struct{}
+
+func NewSearchService() *SearchService {
+	return &SearchService{}
+}
+
+// Terms 按空白拆分搜索词，忽略大小写去重
+func (s *SearchService) Terms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// Highlight 截取文本中第一处命中关键词附近约 width 个字符的片段，命中处用 <mark> 标记
+// 片段已做 HTML 转义，可直接渲染；文本中没有命中时返回空字符串
+func (s *SearchService) Highlight(text string, terms []string, width int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	var patterns [][]rune
+	for _, term := range terms {
+		patterns = append(patterns, []rune(strings.ToLower(term)))
+	}
+
+	// 记录每个位置开始的最长命中长度
+	matches := make([]int, len(runes))
+	first := -1
+	for i := range lower {
+		for _, pattern := range patterns {
+			if len(pattern) > matches[i] && hasRunePrefix(lower[i:], pattern) {
+				matches[i] = len(pattern)
+			}
+		}
+		if matches[i] > 0 && first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start := first - width/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + width
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		if n := matches[i]; n > 0 {
+			stop := i + n
+			if stop > end {
+				stop = end
+			}
+			b.WriteString("<mark>" + html.EscapeString(string(runes[i:stop])) + "</mark>")
+			i = stop
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+		i++
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
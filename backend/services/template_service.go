@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"prompt-manager/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// variablePattern 匹配 {{name}} 形式的模板变量，允许花括号内有空白
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+var variableTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"array":   true,
+	"object":  true,
+}
+
+type TemplateService struct{}
+
+func NewTemplateService() *TemplateService {
+	return &TemplateService{}
+}
+
+// RenderError 渲染时的变量校验错误
+type RenderError struct {
+	Details []string
+}
+
+func (e *RenderError) Error() string {
+	return "invalid template variables: " + strings.Join(e.Details, "; ")
+}
+
+// ExtractVariables 按出现顺序提取内容中的变量名（去重）
+func (t *TemplateService) ExtractVariables(content string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range variablePattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// DetectSchema 根据内容中出现的变量生成变量定义，已声明的变量保留其类型、默认值和描述
+func (t *TemplateService) DetectSchema(content string, declared models.PromptVariables) models.PromptVariables {
+	declaredMap := make(map[string]models.PromptVariable, len(declared))
+	for _, v := range declared {
+		declaredMap[v.Name] = v
+	}
+
+	schema := models.PromptVariables{}
+	for _, name := range t.ExtractVariables(content) {
+		v, ok := declaredMap[name]
+		if !ok {
+			v = models.PromptVariable{Name: name, Type: "string", Required: true}
+		}
+		if v.Type == "" {
+			v.Type = "string"
+		}
+		schema = append(schema, v)
+	}
+	return schema
+}
+
+// ValidateSchema 校验变量定义本身是否合法
+func (t *TemplateService) ValidateSchema(schema models.PromptVariables) error {
+	var details []string
+	for _, v := range schema {
+		if v.Name == "" {
+			details = append(details, "variable name is required")
+			continue
+		}
+		if v.Type != "" && !variableTypes[v.Type] {
+			details = append(details, fmt.Sprintf("variable %s has unsupported type %s", v.Name, v.Type))
+			continue
+		}
+		if v.Default != nil {
+			if err := checkVariableType(v.Type, v.Default); err != nil {
+				details = append(details, fmt.Sprintf("default of variable %s: %v", v.Name, err))
+			}
+		}
+	}
+	if len(details) > 0 {
+		return &RenderError{Details: details}
+	}
+	return nil
+}
+
+// Render 按变量定义校验传入的变量并渲染内容
+func (t *TemplateService) Render(content string, schema models.PromptVariables, variables map[string]any) (string, error) {
+	values := make(map[string]string, len(schema))
+	var details []string
+
+	for _, v := range schema {
+		value, ok := variables[v.Name]
+		if !ok || value == nil {
+			if v.Default != nil {
+				value = v.Default
+			} else if v.Required {
+				details = append(details, fmt.Sprintf("missing required variable %s", v.Name))
+				continue
+			} else {
+				values[v.Name] = ""
+				continue
+			}
+		}
+		if err := checkVariableType(v.Type, value); err != nil {
+			details = append(details, fmt.Sprintf("variable %s: %v", v.Name, err))
+			continue
+		}
+		values[v.Name] = formatVariable(value)
+	}
+	if len(details) > 0 {
+		return "", &RenderError{Details: details}
+	}
+
+	return variablePattern.ReplaceAllStringFunc(content, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	}), nil
+}
+
+// checkVariableType 校验 JSON 解码后的值是否符合变量类型
+func checkVariableType(varType string, value any) error {
+	switch varType {
+	case "", "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected string, got %s", jsonTypeName(value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("expected number, got %s", jsonTypeName(value))
+		}
+	case "integer":
+		f, ok := value.(float64)
+		if !ok || f != math.Trunc(f) {
+			return fmt.Errorf("expected integer, got %s", jsonTypeName(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected boolean, got %s", jsonTypeName(value))
+		}
+	case "array":
+		if _, ok := value.([]any); !ok {
+			return fmt.Errorf("expected array, got %s", jsonTypeName(value))
+		}
+	case "object":
+		if _, ok := value.(map[string]any); !ok {
+			return fmt.Errorf("expected object, got %s", jsonTypeName(value))
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", varType)
+	}
+	return nil
+}
+
+func jsonTypeName(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// formatVariable 字符串原样输出，其余类型输出 JSON 表示
+func formatVariable(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
@@ -12,57 +12,433 @@ func NewVersionService() *VersionService {
 	return &VersionService{}
 }
 
+// Version 语义化版本号（构建元数据不参与比较，解析时忽略）
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+}
+
+// ParseVersion 解析 MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] 形式的版本号，允许前缀 v
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		pre := s[i+1:]
+		s = s[:i]
+		if pre == "" {
+			return v, fmt.Errorf("invalid version: empty pre-release")
+		}
+		v.Prerelease = strings.Split(pre, ".")
+		for _, id := range v.Prerelease {
+			if id == "" {
+				return v, fmt.Errorf("invalid version: empty pre-release identifier")
+			}
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q: %q is not a number", s, part)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// NormalizeVersion 将版本号规范为 MAJOR.MINOR.PATCH[-PRERELEASE]，去掉前缀 v 和构建元数据，
+// 语义化版本相同的版本号规范化后一致；无法解析时返回去掉首尾空白的原值
+func NormalizeVersion(s string) string {
+	v, err := ParseVersion(s)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return v.String()
+}
+
+// IsPrerelease 是否为预发布版本
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare 按语义化版本优先级比较，返回 -1、0 或 1
+func (v Version) Compare(o Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// 正式版本高于同号的预发布版本
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrereleaseID(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.Prerelease), len(o.Prerelease))
+}
+
+// comparePrereleaseID 数字标识按数值比较且低于字母标识，字母标识按 ASCII 比较
+func comparePrereleaseID(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInt(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValidVersion 判断是否为合法的语义化版本号
+func (v *VersionService) IsValidVersion(version string) bool {
+	_, err := ParseVersion(version)
+	return err == nil
+}
+
 // GenerateNextVersion 生成下一个版本号
+// 当前为预发布版本时，与之对应的正式版本即为下一个版本（如 2.0.0-rc.1 的 patch 升级为 2.0.0）
 func (v *VersionService) GenerateNextVersion(currentVersion string, changeType string) string {
 	if currentVersion == "" {
 		return "1.0.0"
 	}
 
-	parts := strings.Split(currentVersion, ".")
-	if len(parts) != 3 {
+	current, err := ParseVersion(currentVersion)
+	if err != nil {
 		return "1.0.0"
 	}
 
-	major, _ := strconv.Atoi(parts[0])
-	minor, _ := strconv.Atoi(parts[1])
-	patch, _ := strconv.Atoi(parts[2])
+	next := Version{Major: current.Major, Minor: current.Minor, Patch: current.Patch}
+	pre := current.IsPrerelease()
 
 	switch changeType {
 	case "major":
-		major++
-		minor = 0
-		patch = 0
+		if !pre || current.Minor != 0 || current.Patch != 0 {
+			next.Major++
+			next.Minor = 0
+			next.Patch = 0
+		}
 	case "minor":
-		minor++
-		patch = 0
-	case "patch":
-		patch++
+		if !pre || current.Patch != 0 {
+			next.Minor++
+			next.Patch = 0
+		}
 	default:
-		patch++
+		if !pre {
+			next.Patch++
+		}
 	}
 
-	return fmt.Sprintf("%d.%d.%d", major, minor, patch)
+	return next.String()
 }
 
-// CompareVersions 比较两个版本号
+// CompareVersions 按语义化版本比较两个版本号，返回 -1、0 或 1
+// 无法解析的版本号低于任何合法版本，两者都无法解析时按字符串比较
 func (v *VersionService) CompareVersions(version1, version2 string) int {
-	parts1 := strings.Split(version1, ".")
-	parts2 := strings.Split(version2, ".")
+	v1, err1 := ParseVersion(version1)
+	v2, err2 := ParseVersion(version2)
+	switch {
+	case err1 == nil && err2 == nil:
+		return v1.Compare(v2)
+	case err1 == nil:
+		return 1
+	case err2 == nil:
+		return -1
+	default:
+		return strings.Compare(version1, version2)
+	}
+}
 
-	if len(parts1) != 3 || len(parts2) != 3 {
-		return 0
+// Highest 返回版本号最高的下标（包括预发布版本），列表为空时返回 -1
+func (v *VersionService) Highest(versions []string) int {
+	best := -1
+	for i, version := range versions {
+		if best < 0 || v.CompareVersions(version, versions[best]) > 0 {
+			best = i
+		}
+	}
+	return best
+}
+
+// Latest 返回最新版本的下标：优先选择最高的正式版本，没有正式版本时选择最高的预发布版本
+func (v *VersionService) Latest(versions []string) int {
+	best := -1
+	for i, version := range versions {
+		parsed, err := ParseVersion(version)
+		if err != nil || parsed.IsPrerelease() {
+			continue
+		}
+		if best < 0 || v.CompareVersions(version, versions[best]) > 0 {
+			best = i
+		}
 	}
+	if best < 0 {
+		return v.Highest(versions)
+	}
+	return best
+}
+
+// MaxSatisfying 返回满足版本范围的最高版本的下标，没有满足的版本时返回 -1
+func (v *VersionService) MaxSatisfying(versions []string, r *VersionRange) int {
+	best := -1
+	for i, version := range versions {
+		parsed, err := ParseVersion(version)
+		if err != nil || !r.Match(parsed) {
+			continue
+		}
+		if best < 0 || v.CompareVersions(version, versions[best]) > 0 {
+			best = i
+		}
+	}
+	return best
+}
 
-	for i := 0; i < 3; i++ {
-		num1, _ := strconv.Atoi(parts1[i])
-		num2, _ := strconv.Atoi(parts2[i])
+// VersionRange 版本范围，由 || 分隔的多组条件构成，组内条件需同时满足
+type VersionRange struct {
+	sets [][]comparator
+}
 
-		if num1 > num2 {
-			return 1
-		} else if num1 < num2 {
-			return -1
+type comparator struct {
+	op      string // = > >= < <=
+	version Version
+}
+
+func (c comparator) match(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// IsVersionRange 判断参数是否为版本范围而非具体版本号
+func IsVersionRange(s string) bool {
+	if _, err := ParseVersion(s); err == nil {
+		return false
+	}
+	_, err := ParseVersionRange(s)
+	return err == nil
+}
+
+// ParseVersionRange 解析版本范围，支持 ^1.2、~1.4.0、1.x、>=1.0.0 <2.0.0 以及 || 组合
+func ParseVersionRange(s string) (*VersionRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty version range")
+	}
+
+	r := &VersionRange{}
+	for _, part := range strings.Split(s, "||") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version range %q", s)
+		}
+		var set []comparator
+		for _, field := range fields {
+			comparators, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators...)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// Match 判断版本是否满足范围
+// 与 npm 一致：预发布版本只有在范围中某个条件的版本号与之 MAJOR.MINOR.PATCH 相同且同为预发布时才匹配
+func (r *VersionRange) Match(v Version) bool {
+	for _, set := range r.sets {
+		if matchSet(set, v) {
+			return true
 		}
 	}
+	return false
+}
 
-	return 0
-}
\ No newline at end of file
+func matchSet(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.match(v) {
+			return false
+		}
+	}
+	if !v.IsPrerelease() {
+		return true
+	}
+	for _, c := range set {
+		cv := c.version
+		if cv.IsPrerelease() && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// parseComparator 将单个条件展开为一个或多个比较条件
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			s = s[len(prefix):]
+			break
+		}
+	}
+
+	v, n, err := parsePartialVersion(s)
+	if err != nil {
+		return nil, err
+	}
+	anyVersion := []comparator{{op: ">=", version: Version{}}}
+
+	switch op {
+	case "^":
+		if n == 0 {
+			return anyVersion, nil
+		}
+		upper := Version{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && n == 3 && v.Minor == 0:
+			upper = Version{Patch: v.Patch + 1}
+		case v.Major == 0 && n >= 2:
+			upper = Version{Minor: v.Minor + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case "~":
+		if n == 0 {
+			return anyVersion, nil
+		}
+		upper := Version{Major: v.Major + 1}
+		if n >= 2 {
+			upper = Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case ">":
+		switch n {
+		case 0:
+			return []comparator{{"<", Version{}}}, nil
+		case 1:
+			return []comparator{{">=", Version{Major: v.Major + 1}}}, nil
+		case 2:
+			return []comparator{{">=", Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		return []comparator{{"<", v}}, nil
+	case "<=":
+		switch n {
+		case 0:
+			return anyVersion, nil
+		case 1:
+			return []comparator{{"<", Version{Major: v.Major + 1}}}, nil
+		case 2:
+			return []comparator{{"<", Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	default:
+		// 精确版本或通配范围（1、1.x、1.2.*）
+		switch n {
+		case 0:
+			return anyVersion, nil
+		case 1:
+			return []comparator{{">=", v}, {"<", Version{Major: v.Major + 1}}}, nil
+		case 2:
+			return []comparator{{">=", v}, {"<", Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+		}
+		return []comparator{{"=", v}}, nil
+	}
+}
+
+// parsePartialVersion 解析可省略或使用 x/* 通配的版本号，返回解析结果和实际指定的段数
+func parsePartialVersion(s string) (Version, int, error) {
+	var v Version
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		full, err := ParseVersion(s)
+		if err != nil {
+			return v, 0, err
+		}
+		return full, 3, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 || s == "" {
+		return v, 0, fmt.Errorf("invalid version range %q", s)
+	}
+	nums := make([]int, 3)
+	n := 0
+	for _, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 0 {
+			return v, 0, fmt.Errorf("invalid version range %q", s)
+		}
+		nums[n] = num
+		n++
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, n, nil
+}
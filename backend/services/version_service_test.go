@@ -0,0 +1,19 @@
+package services
+
+import "testing"
+
+func TestNormalizeVersion(t *testing.T) {
+	cases := map[string]string{
+		"1.0.0":          "1.0.0",
+		"v1.0.0":         "1.0.0",
+		" 1.0.0+build.5": "1.0.0",
+		"v2.0.0-rc.1+x":  "2.0.0-rc.1",
+		"01.2.3":         "1.2.3",
+		"latest":         "latest",
+	}
+	for in, want := range cases {
+		if got := NormalizeVersion(in); got != want {
+			t.Errorf("NormalizeVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import CategoryManagement from './pages/CategoryManagement';
 import ImportExport from './pages/ImportExport';
 import IntegrationTutorial from './pages/IntegrationTutorial';
 import Settings from './pages/Settings';
+import Login from './pages/Login';
 import { ErrorBoundary } from './components/ErrorBoundary';
 import { ThemeProvider } from './contexts/ThemeContext';
 import './index.css';
@@ -21,6 +22,7 @@ function App() {
           <div className="min-h-screen bg-gray-50 dark:bg-gray-900 transition-colors duration-200">
             <Routes>
               <Route path="/" element={<Home />} />
+              <Route path="/login" element={<Login />} />
               <Route path="/settings" element={<Settings />} />
               <Route path="/project/:id" element={<ProjectDetail />} />
               <Route path="/version/:id" element={<VersionDetail />} />
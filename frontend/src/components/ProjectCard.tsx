@@ -23,11 +23,12 @@ export const ProjectCard: React.FC<ProjectCardProps> = ({ project, onClick, onDe
   };
 
   const promptCount = React.useMemo(() => {
+    if (project.prompt_entities) return project.prompt_entities.length;
     if (!project.prompts) return 0;
     // 使用 Set 去重 prompt.name，计算不重复的提示词数量
     const uniqueNames = new Set(project.prompts.map(p => p.name));
     return uniqueNames.size;
-  }, [project.prompts]);
+  }, [project.prompt_entities, project.prompts]);
   
   const tagCount = project.tags?.length || 0;
 
@@ -20,13 +20,51 @@ export const IntegrationTutorial: React.FC = () => {
 
 **参数:**
 - \`name\`: (必填) 提示词名称
-- \`version\`: (可选) 特定版本号，如果不传则返回最新版本
+- \`version\`: (可选) 版本号或版本范围。精确版本如 \`1.2.0\`、\`2.0.0-rc.1\`；范围如 \`^1.2\`（>=1.2.0 <2.0.0）、\`~1.4.0\`（>=1.4.0 <1.5.0）、\`1.x\`，返回满足范围的最高版本。不传则按语义化版本返回最新的正式版本（预发布版本需通过精确版本或包含预发布号的范围获取）
+- \`label\`: (可选) 发布标签，如 \`production\`、\`staging\`，返回该标签当前指向的版本（不能与 \`version\` 同时使用）
 - \`tag\`: (可选) 标签筛选
 
 **响应:**
 \`\`\`json
 {
-  "content": "您的提示词内容..."
+  "content": "您的提示词内容...",
+  "version": "1.0.3"
+}
+\`\`\`
+
+### 认证
+
+服务端开启 \`auth.enabled\` 后，请求需要携带项目级 API Key（通过 \`POST /api/api-keys\` 创建，明文仅在创建时返回一次）：
+
+\`\`\`
+X-API-Key: pm_xxxxxxxx
+\`\`\`
+
+也可以使用 \`Authorization: Bearer pm_xxxxxxxx\`。只读 Key 可以调用所有 SDK 接口。
+
+### 服务端渲染模板变量
+
+**Endpoint:** \`POST /api/projects/{project_id}/sdk/render\`
+
+提示词中的 \`{{变量名}}\` 会在保存时自动识别为变量，可在变量定义中设置类型、是否必填、默认值和描述。
+
+**请求体:**
+\`\`\`json
+{
+  "name": "提示词名称",
+  "label": "production",
+  "variables": { "user_name": "张三", "max_items": 5 }
+}
+\`\`\`
+
+\`version\`、\`label\`、\`tag\` 的含义与获取接口一致。缺少必填变量或类型不匹配时返回 400，\`details\` 字段中列出具体原因。
+
+**响应:**
+\`\`\`json
+{
+  "content": "渲染后的提示词内容...",
+  "version": "1.0.3",
+  "label": "production"
 }
 \`\`\`
 
@@ -184,7 +222,7 @@ getPromptContent('your_project_id', 'welcome_message').then(content => {
 
 1. **缓存**: 建议在应用端对提示词进行适当缓存（例如 5-10 分钟），避免频繁请求 API。
 2. **错误处理**: 在网络请求失败时，应有默认的提示词作为后备 (Fallback)。
-3. **版本控制**: 生产环境建议指定 \`version\` 参数以锁定特定版本的提示词，避免意外变更。
+3. **版本控制**: 生产环境建议使用 \`label=production\` 获取提示词，通过移动发布标签完成上线和回退；也可以指定 \`version\` 参数锁定特定版本，避免意外变更。
 `;
 
   return (
@@ -45,10 +45,11 @@ export const ProjectDetail: React.FC = () => {
   }, [id]);
 
   const promptCount = React.useMemo(() => {
+    if (project?.prompt_entities) return project.prompt_entities.length;
     if (!project?.prompts) return 0;
     const uniqueNames = new Set(project.prompts.map(p => p.name));
     return uniqueNames.size;
-  }, [project?.prompts]);
+  }, [project?.prompt_entities, project?.prompts]);
 
   const loadProject = async () => {
     try {
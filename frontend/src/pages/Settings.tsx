@@ -4,7 +4,7 @@ import { useNavigate } from 'react-router-dom';
 import { apiService } from '../services/api';
 import { ThemeToggle } from '../components/ThemeToggle';
 
-type ProviderType = 'aliyun' | 'deepseek' | 'doubao' | 'glm' | 'kimi';
+type ProviderType = 'aliyun' | 'deepseek' | 'doubao' | 'glm' | 'kimi' | 'openai' | 'anthropic';
 
 interface ProviderConfig {
   name: string;
@@ -44,6 +44,18 @@ const PROVIDERS: Record<ProviderType, ProviderConfig> = {
     api_url: 'https://api.moonshot.cn/v1/chat/completions',
     model: 'moonshot-v1-8k',
   },
+  openai: {
+    name: 'openai',
+    displayName: 'OpenAI',
+    api_url: 'https://api.openai.com/v1/chat/completions',
+    model: 'gpt-4o-mini',
+  },
+  anthropic: {
+    name: 'anthropic',
+    displayName: 'Anthropic',
+    api_url: 'https://api.anthropic.com/v1/messages',
+    model: 'claude-sonnet-4-5',
+  },
 };
 
 const Settings: React.FC = () => {
@@ -68,6 +80,12 @@ const Settings: React.FC = () => {
     kimi_api_key: '',
     kimi_api_url: PROVIDERS.kimi.api_url,
     kimi_model: PROVIDERS.kimi.model,
+    openai_api_key: '',
+    openai_api_url: PROVIDERS.openai.api_url,
+    openai_model: PROVIDERS.openai.model,
+    anthropic_api_key: '',
+    anthropic_api_url: PROVIDERS.anthropic.api_url,
+    anthropic_model: PROVIDERS.anthropic.model,
   });
 
   useEffect(() => {
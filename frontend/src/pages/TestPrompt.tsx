@@ -7,7 +7,7 @@ import ReactMarkdown from 'react-markdown';
 import remarkGfm from 'remark-gfm';
 import remarkBreaks from 'remark-breaks';
 import { encode } from 'gpt-tokenizer';
-import { Prompt } from '../types/models';
+import { Prompt, ChatMetrics } from '../types/models';
 import { ThemeToggle } from '../components/ThemeToggle';
 
 interface Message {
@@ -16,7 +16,7 @@ interface Message {
   content: string;
 }
 
-type ProviderType = 'aliyun' | 'deepseek' | 'doubao' | 'glm' | 'kimi';
+type ProviderType = 'aliyun' | 'deepseek' | 'doubao' | 'glm' | 'kimi' | 'openai' | 'anthropic';
 
 interface ProviderConfig {
   name: string;
@@ -26,6 +26,18 @@ interface ProviderConfig {
   suggestedModels: string[];
 }
 
+// formatCurrency 费用币种符号，未知币种直接显示代码
+const formatCurrency = (currency?: string) => {
+  switch (currency) {
+    case 'CNY':
+      return '¥';
+    case 'USD':
+      return '$';
+    default:
+      return currency ? `${currency} ` : '';
+  }
+};
+
 const PROVIDERS: Record<ProviderType, ProviderConfig> = {
   aliyun: {
     name: 'aliyun',
@@ -62,6 +74,20 @@ const PROVIDERS: Record<ProviderType, ProviderConfig> = {
     model: 'moonshot-v1-8k',
     suggestedModels: ['moonshot-v1-8k', 'moonshot-v1-32k', 'moonshot-v1-128k'],
   },
+  openai: {
+    name: 'openai',
+    displayName: 'OpenAI',
+    api_url: 'https://api.openai.com/v1/chat/completions',
+    model: 'gpt-4o-mini',
+    suggestedModels: ['gpt-4o-mini', 'gpt-4o', 'gpt-4.1', 'gpt-4.1-mini'],
+  },
+  anthropic: {
+    name: 'anthropic',
+    displayName: 'Anthropic',
+    api_url: 'https://api.anthropic.com/v1/messages',
+    model: 'claude-sonnet-4-5',
+    suggestedModels: ['claude-sonnet-4-5', 'claude-opus-4-1', 'claude-3-5-haiku-latest'],
+  },
 };
 
 interface ModelSettings {
@@ -108,6 +134,7 @@ export const TestPrompt: React.FC = () => {
   const [streamAbort, setStreamAbort] = useState<(() => void) | null>(null);
   const [tokenCount, setTokenCount] = useState(0);
   const [cost, setCost] = useState(0);
+  const [metrics, setMetrics] = useState<ChatMetrics | null>(null);
   const [showCostSettings, setShowCostSettings] = useState(false);
   const [inputPrice, setInputPrice] = useState(0.002);
   const [outputPrice, setOutputPrice] = useState(0.006);
@@ -123,6 +150,7 @@ export const TestPrompt: React.FC = () => {
     topP: 0.8,
     maxTokens: 2000
   });
+  const [customProviders, setCustomProviders] = useState<Record<string, ProviderConfig>>({});
   const [copied, setCopied] = useState(false);
   const [variablePrefix, setVariablePrefix] = useState('{{');
   const [variableSuffix, setVariableSuffix] = useState('}}');
@@ -204,6 +232,32 @@ export const TestPrompt: React.FC = () => {
     }
   }, [id]);
 
+  useEffect(() => {
+    loadCustomProviders();
+  }, []);
+
+  // 自定义服务商追加在内置服务商之后
+  const loadCustomProviders = async () => {
+    try {
+      const res = await apiService.getProviders();
+      const configs: Record<string, ProviderConfig> = {};
+      res.data.forEach(p => {
+        configs[p.name] = {
+          name: p.name,
+          displayName: p.display_name || p.name,
+          api_url: p.base_url,
+          model: p.default_model,
+          suggestedModels: p.models.length > 0 ? p.models : [p.default_model],
+        };
+      });
+      setCustomProviders(configs);
+    } catch (error) {
+      console.error('Failed to load custom providers:', error);
+    }
+  };
+
+  const allProviders: Record<string, ProviderConfig> = { ...PROVIDERS, ...customProviders };
+
   const loadPrompt = async (promptId: string) => {
     try {
       const prompt = await apiService.getPrompt(promptId);
@@ -362,8 +416,11 @@ export const TestPrompt: React.FC = () => {
 
     setLoading(true);
     setResponse('');
+    setMetrics(null);
     
     // Replace variables
+    // 默认的 {{ }} 变量交给服务端渲染，与 SDK 渲染接口保持一致
+    const serverRender = variablePrefix === '{{' && variableSuffix === '}}';
     const currentPromptContent = getCurrentPromptContent();
     const apiMessages = messages.map(({ role, content }) => {
       let newContent = content;
@@ -373,7 +430,7 @@ export const TestPrompt: React.FC = () => {
         newContent = currentPromptContent;
       }
       
-      if (variablePrefix && variableSuffix) {
+      if (!serverRender && variablePrefix && variableSuffix) {
           const escapedPrefix = escapeRegExp(variablePrefix);
           const escapedSuffix = escapeRegExp(variableSuffix);
           
@@ -405,7 +462,8 @@ export const TestPrompt: React.FC = () => {
           setLoading(false);
           setStreamAbort(null);
       },
-      modelSettings // Passing settings
+      serverRender ? { ...modelSettings, variables: variableValues } : modelSettings, // Passing settings
+      setMetrics
     );
     
     setStreamAbort(() => abort);
@@ -587,16 +645,16 @@ export const TestPrompt: React.FC = () => {
                                 <select
                                     value={modelSettings.provider}
                                     onChange={(e) => {
-                                        const newProvider = e.target.value as ProviderType;
+                                        const newProvider = e.target.value;
                                         setModelSettings({
                                             ...modelSettings,
                                             provider: newProvider,
-                                            model: PROVIDERS[newProvider].model
+                                            model: allProviders[newProvider]?.model ?? ''
                                         });
                                     }}
                                     className="w-full text-sm border-gray-200 dark:border-gray-600 bg-white dark:bg-gray-700 text-gray-900 dark:text-white rounded-md focus:ring-indigo-500 dark:focus:ring-indigo-400 focus:border-indigo-500"
                                 >
-                                    {Object.entries(PROVIDERS).map(([key, config]) => (
+                                    {Object.entries(allProviders).map(([key, config]) => (
                                         <option key={key} value={key}>{config.displayName}</option>
                                     ))}
                                 </select>
@@ -611,7 +669,7 @@ export const TestPrompt: React.FC = () => {
                                     placeholder="输入模型名称..."
                                 />
                                 <div className="flex flex-wrap gap-2">
-                                    {PROVIDERS[modelSettings.provider as ProviderType]?.suggestedModels.map(m => (
+                                    {allProviders[modelSettings.provider]?.suggestedModels.map(m => (
                                         <button
                                             key={m}
                                             onClick={() => setModelSettings({...modelSettings, model: m})}
@@ -683,13 +741,34 @@ export const TestPrompt: React.FC = () => {
                   className="flex flex-col items-end px-3 py-1 bg-gray-50 dark:bg-gray-700 rounded-lg border border-gray-100 dark:border-gray-600 hover:bg-gray-100 dark:hover:bg-gray-600 transition-colors cursor-pointer"
                   title="点击设置模型单价"
                 >
-                  <div className="flex items-center text-xs text-gray-500 dark:text-gray-400 space-x-2">
-                    <Calculator className="w-3 h-3" />
-                    <span>Tokens: {tokenCount}</span>
-                  </div>
-                  <div className="text-xs font-medium text-gray-700 dark:text-gray-300">
-                    ≈ ¥{cost.toFixed(5)}
-                  </div>
+                  {metrics && metrics.total_tokens > 0 ? (
+                    <>
+                      {/* 服务商返回的实际用量 */}
+                      <div className="flex items-center text-xs text-gray-500 dark:text-gray-400 space-x-2">
+                        <Calculator className="w-3 h-3" />
+                        <span>Tokens: {metrics.prompt_tokens} + {metrics.completion_tokens}</span>
+                        <span>
+                          {metrics.time_to_first_token_ms ? `首字 ${metrics.time_to_first_token_ms}ms / ` : ''}
+                          {metrics.latency_ms}ms
+                        </span>
+                      </div>
+                      <div className="text-xs font-medium text-gray-700 dark:text-gray-300">
+                        {metrics.cost !== undefined
+                          ? `${formatCurrency(metrics.currency)}${metrics.cost.toFixed(5)}`
+                          : `≈ ¥${cost.toFixed(5)}`}
+                      </div>
+                    </>
+                  ) : (
+                    <>
+                      <div className="flex items-center text-xs text-gray-500 dark:text-gray-400 space-x-2">
+                        <Calculator className="w-3 h-3" />
+                        <span>Tokens: {tokenCount}</span>
+                      </div>
+                      <div className="text-xs font-medium text-gray-700 dark:text-gray-300">
+                        ≈ ¥{cost.toFixed(5)}
+                      </div>
+                    </>
+                  )}
                 </button>
 
                 {showCostSettings && (
@@ -1,4 +1,4 @@
-import { Project, Prompt, Tag, ApiResponse, DiffResult } from '../types/models';
+import { Project, Prompt, PromptEntity, PromptEntityListItem, Tag, ApiResponse, DiffResult, User, CustomProvider, CustomProviderInput, ChatMetrics, Assertion, AssertionReport, TestTarget, TestTargetResult, TestRun, Dataset, DatasetRow, DatasetRowInput, Evaluation, EvaluationResult, EvaluationComparison, PromptTestCase, PromptTestCaseInput, PromptCheck, PromptReview, PromptReviewSummary, ReviewStatus, PromptStatus, TrashItem, TrashType, ListParams, SearchHit } from '../types/models';
 
 interface Env {
   API_URL: string;
@@ -12,24 +12,81 @@ declare global {
 
 const API_BASE_URL = (window.ENV?.API_URL || 'http://localhost:7788') + '/api';
 
+const TOKEN_KEY = 'pm_token';
+
+export const authStorage = {
+  getToken: (): string | null => localStorage.getItem(TOKEN_KEY),
+  setToken: (token: string) => localStorage.setItem(TOKEN_KEY, token),
+  clearToken: () => localStorage.removeItem(TOKEN_KEY),
+};
+
+// 附加登录令牌
+const authHeaders = (): Record<string, string> => {
+  const token = authStorage.getToken();
+  return token ? { Authorization: `Bearer ${token}` } : {};
+};
+
+// 未登录或会话过期时跳转到登录页
+const handleUnauthorized = (response: Response) => {
+  if (response.status === 401 && window.location.pathname !== '/login') {
+    authStorage.clearToken();
+    window.location.href = '/login';
+  }
+};
+
 class ApiService {
   private async request<T>(endpoint: string, options?: RequestInit): Promise<T> {
     const url = `${API_BASE_URL}${endpoint}`;
     const response = await fetch(url, {
+      ...options,
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
         ...options?.headers,
       },
-      ...options,
     });
 
     if (!response.ok) {
+      handleUnauthorized(response);
       throw new Error(`API request failed: ${response.statusText}`);
     }
 
     return response.json();
   }
 
+  // listParams 生成列表接口的分页、排序和字段选择参数
+  private listParams(list?: ListParams): URLSearchParams {
+    const params = new URLSearchParams();
+    if (list?.page) params.append('page', list.page.toString());
+    if (list?.page_size) params.append('page_size', list.page_size.toString());
+    if (list?.cursor !== undefined) params.append('cursor', list.cursor);
+    if (list?.sort) params.append('sort', list.sort);
+    if (list?.fields?.length) params.append('fields', list.fields.join(','));
+    return params;
+  }
+
+  // 登录认证
+  async login(username: string, password: string): Promise<{ token: string; expires_at: string; user: User }> {
+    const result = await this.request<{ token: string; expires_at: string; user: User }>('/auth/login', {
+      method: 'POST',
+      body: JSON.stringify({ username, password }),
+    });
+    authStorage.setToken(result.token);
+    return result;
+  }
+
+  async logout(): Promise<void> {
+    try {
+      await this.request<void>('/auth/logout', { method: 'POST' });
+    } finally {
+      authStorage.clearToken();
+    }
+  }
+
+  async getCurrentUser(): Promise<{ user: User }> {
+    return this.request<{ user: User }>('/auth/me');
+  }
+
   // 设置管理
   async getSettings(): Promise<Record<string, string>> {
     return this.request<Record<string, string>>('/settings');
@@ -42,21 +99,46 @@ class ApiService {
     });
   }
 
-  async optimizePrompt(prompt: string, provider?: string): Promise<{ optimized_prompt: string }> {
-    return this.request<{ optimized_prompt: string }>('/optimize-prompt', {
+  // 自定义模型服务商
+  async getProviders(): Promise<ApiResponse<CustomProvider[]> & { builtin: string[] }> {
+    return this.request<ApiResponse<CustomProvider[]> & { builtin: string[] }>('/providers');
+  }
+
+  async createProvider(data: CustomProviderInput): Promise<CustomProvider> {
+    return this.request<CustomProvider>('/providers', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async updateProvider(id: string, data: CustomProviderInput): Promise<CustomProvider> {
+    return this.request<CustomProvider>(`/providers/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async deleteProvider(id: string): Promise<void> {
+    return this.request<void>(`/providers/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
+  async optimizePrompt(prompt: string, provider?: string): Promise<{ optimized_prompt: string; metrics: ChatMetrics; run_id: string }> {
+    return this.request<{ optimized_prompt: string; metrics: ChatMetrics; run_id: string }>('/optimize-prompt', {
       method: 'POST',
       body: JSON.stringify({ prompt, provider }),
     });
   }
 
-  async testPrompt(messages: { role: string; content: string }[], options?: { model?: string; temperature?: number; topP?: number; maxTokens?: number; provider?: string }): Promise<{ response: string }> {
-    return this.request<{ response: string }>('/test-prompt', {
+  async testPrompt(messages: { role: string; content: string }[], options?: { model?: string; temperature?: number; topP?: number; maxTokens?: number; provider?: string; assertions?: Assertion[] }): Promise<{ response: string; metrics: ChatMetrics; run_id: string; assertions?: AssertionReport }> {
+    return this.request<{ response: string; metrics: ChatMetrics; run_id: string; assertions?: AssertionReport }>('/test-prompt', {
       method: 'POST',
       body: JSON.stringify({ messages, ...options }),
     });
   }
 
-  testPromptStream(messages: { role: string; content: string }[], onData: (text: string) => void, onError: (error: string) => void, onComplete?: () => void, options?: { model?: string; temperature?: number; topP?: number; maxTokens?: number; provider?: string }): () => void {
+  testPromptStream(messages: { role: string; content: string }[], onData: (text: string) => void, onError: (error: string) => void, onComplete?: () => void, options?: { model?: string; temperature?: number; topP?: number; maxTokens?: number; provider?: string; variables?: Record<string, unknown>; prompt_id?: string; assertions?: Assertion[] }, onMetrics?: (metrics: ChatMetrics) => void, onAssertions?: (report: AssertionReport) => void): () => void {
     const controller = new AbortController();
     const signal = controller.signal;
     let reader: ReadableStreamDefaultReader<Uint8Array> | null = null;
@@ -78,12 +160,14 @@ class ApiService {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
       body: JSON.stringify({ messages, stream: true, ...options }),
       signal,
     })
       .then(async (response) => {
         if (!response.ok) {
+          handleUnauthorized(response);
           throw new Error(`API request failed: ${response.statusText}`);
         }
 
@@ -120,6 +204,16 @@ class ApiService {
                         onData(json.text);
                         continue;
                       }
+                      // 结束时服务端发送本次调用的用量、耗时和费用
+                      if (json && typeof json === 'object' && 'metrics' in json) {
+                        if (onMetrics) onMetrics(json.metrics);
+                        continue;
+                      }
+                      // 配置了断言时，最后发送断言结果
+                      if (json && typeof json === 'object' && 'assertions' in json) {
+                        if (onAssertions) onAssertions(json.assertions);
+                        continue;
+                      }
                     } catch (e) {
                       // Not JSON, fall back to raw text
                     }
@@ -153,6 +247,103 @@ class ApiService {
     return cleanup;
   }
 
+  // 将同一组消息并行发送给多个模型对比
+  async compareModels(messages: { role: string; content: string }[], targets: TestTarget[], options?: { temperature?: number; top_p?: number; max_tokens?: number; variables?: Record<string, unknown>; prompt_id?: string; assertions?: Assertion[] }): Promise<{ results: TestTargetResult[] }> {
+    return this.request<{ results: TestTargetResult[] }>('/test-prompt', {
+      method: 'POST',
+      body: JSON.stringify({ messages, targets, ...options }),
+    });
+  }
+
+  // 流式对比多个模型，每个事件都带有 target 下标
+  compareModelsStream(
+    messages: { role: string; content: string }[],
+    targets: TestTarget[],
+    handlers: {
+      onData: (target: number, text: string) => void;
+      onMetrics?: (target: number, metrics: ChatMetrics, runId: string) => void;
+      onTargetError?: (target: number, error: string, errorType?: string) => void;
+      onAssertions?: (target: number, report: AssertionReport) => void;
+      onError: (error: string) => void;
+      onComplete?: () => void;
+    },
+    options?: { temperature?: number; top_p?: number; max_tokens?: number; variables?: Record<string, unknown>; prompt_id?: string; assertions?: Assertion[] }
+  ): () => void {
+    const controller = new AbortController();
+
+    fetch(`${API_BASE_URL}/test-prompt`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        ...authHeaders(),
+      },
+      body: JSON.stringify({ messages, targets, stream: true, ...options }),
+      signal: controller.signal,
+    })
+      .then(async (response) => {
+        if (!response.ok) {
+          handleUnauthorized(response);
+          const error = await response.json().catch(() => ({}));
+          throw new Error(error.error || `API request failed: ${response.statusText}`);
+        }
+
+        const reader = response.body?.getReader();
+        if (!reader) {
+          throw new Error('Response body is not readable');
+        }
+
+        const decoder = new TextDecoder();
+        let buffer = '';
+        let event = 'message';
+        while (true) {
+          const { done, value } = await reader.read();
+          if (done) break;
+
+          buffer += decoder.decode(value, { stream: true });
+          const lines = buffer.split('\n');
+          buffer = lines.pop() || '';
+
+          for (const line of lines) {
+            const trimmedLine = line.trim();
+            if (trimmedLine.startsWith('event:')) {
+              event = trimmedLine.substring(6).trim();
+              continue;
+            }
+            if (!trimmedLine.startsWith('data:')) continue;
+
+            try {
+              const json = JSON.parse(trimmedLine.substring(5).trim());
+              switch (event) {
+                case 'message':
+                  handlers.onData(json.target, json.text);
+                  break;
+                case 'metrics':
+                  handlers.onMetrics?.(json.target, json.metrics, json.run_id);
+                  break;
+                case 'error':
+                  handlers.onTargetError?.(json.target, json.error, json.error_type);
+                  break;
+                case 'assertions':
+                  handlers.onAssertions?.(json.target, json.assertions);
+                  break;
+              }
+            } catch (e) {
+              console.error('Error parsing SSE data:', e);
+            }
+            event = 'message';
+          }
+        }
+        handlers.onComplete?.();
+      })
+      .catch((err) => {
+        if (err.name !== 'AbortError') {
+          handlers.onError(err.message);
+        }
+      });
+
+    return () => controller.abort();
+  }
+
   optimizePromptStream(prompt: string, onData: (text: string) => void, onError: (error: string) => void): () => void {
     const controller = new AbortController();
     const signal = controller.signal;
@@ -175,12 +366,14 @@ class ApiService {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
       body: JSON.stringify({ prompt, stream: true }),
       signal,
     })
       .then(async (response) => {
         if (!response.ok) {
+          handleUnauthorized(response);
           throw new Error(`API request failed: ${response.statusText}`);
         }
 
@@ -225,6 +418,9 @@ class ApiService {
                         onData(json.text);
                         continue;
                       }
+                      if (json && typeof json === 'object' && 'metrics' in json) {
+                        continue;
+                      }
                     } catch (e) {
                       // Not JSON, fall back to raw text (legacy format)
                     }
@@ -263,8 +459,8 @@ class ApiService {
   }
 
   // 项目管理
-  async getProjects(search?: string): Promise<ApiResponse<Project[]>> {
-    const params = new URLSearchParams();
+  async getProjects(search?: string, list?: ListParams): Promise<ApiResponse<Project[]>> {
+    const params = this.listParams(list);
     if (search) params.append('search', search);
     
     return this.request<ApiResponse<Project[]>>(`/projects?${params}`);
@@ -300,20 +496,52 @@ class ApiService {
     version?: string;
     name?: string;
     category?: string;
+    status?: PromptStatus | PromptStatus[];
     start_date?: string;
     end_date?: string;
-  }): Promise<ApiResponse<Prompt[]>> {
-    const queryParams = new URLSearchParams();
+  } & ListParams): Promise<ApiResponse<Prompt[]>> {
+    const queryParams = this.listParams(params);
     if (params?.tag) queryParams.append('tag', params.tag);
     if (params?.version) queryParams.append('version', params.version);
     if (params?.name) queryParams.append('name', params.name);
     if (params?.category) queryParams.append('category', params.category);
+    if (params?.status) queryParams.append('status', ([] as PromptStatus[]).concat(params.status).join(','));
     if (params?.start_date) queryParams.append('start_date', params.start_date);
     if (params?.end_date) queryParams.append('end_date', params.end_date);
 
     return this.request<ApiResponse<Prompt[]>>(`/projects/${projectId}/prompts?${queryParams}`);
   }
 
+  // 提示词实体
+  async getPromptEntities(projectId: string, params?: {
+    label?: string;
+    category?: string;
+    search?: string;
+  }): Promise<ApiResponse<PromptEntityListItem[]>> {
+    const queryParams = new URLSearchParams();
+    if (params?.label) queryParams.append('label', params.label);
+    if (params?.category) queryParams.append('category', params.category);
+    if (params?.search) queryParams.append('search', params.search);
+
+    return this.request<ApiResponse<PromptEntityListItem[]>>(`/projects/${projectId}/prompt-entities?${queryParams}`);
+  }
+
+  async getPromptEntityVersions(entityId: string): Promise<ApiResponse<Prompt[]>> {
+    return this.request<ApiResponse<Prompt[]>>(`/prompt-entities/${entityId}/versions`);
+  }
+
+  async updatePromptEntity(entityId: string, data: {
+    name?: string;
+    description?: string;
+    category?: string;
+    tag_ids?: string[];
+  }): Promise<PromptEntity> {
+    return this.request<PromptEntity>(`/prompt-entities/${entityId}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    });
+  }
+
   async getPrompt(id: string): Promise<Prompt> {
     return this.request<Prompt>(`/prompts/${id}`);
   }
@@ -324,6 +552,7 @@ class ApiService {
     tag_ids?: string[];
     category?: string;
     description?: string;
+    status?: 'draft' | 'published';
   }): Promise<Prompt> {
     return this.request<Prompt>(`/projects/${projectId}/prompts`, {
       method: 'POST',
@@ -339,6 +568,7 @@ class ApiService {
     tag_ids?: string[];
     bump?: 'major' | 'minor' | 'patch' | 'none';
     keep_version?: boolean;
+    status?: 'draft' | 'published';
   }): Promise<Prompt> {
     return this.request<Prompt>(`/prompts/${id}`, {
       method: 'PUT',
@@ -366,9 +596,223 @@ class ApiService {
     });
   }
 
+  // 测试运行记录
+  async getRuns(params?: { project_id?: string; prompt_id?: string; kind?: string; provider?: string; model?: string; status?: string; q?: string; page?: number; page_size?: number }): Promise<ApiResponse<TestRun[]>> {
+    const queryParams = new URLSearchParams();
+    Object.entries(params || {}).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') queryParams.append(key, String(value));
+    });
+
+    return this.request<ApiResponse<TestRun[]>>(`/runs?${queryParams}`);
+  }
+
+  async getPromptRuns(promptId: string, page = 1, pageSize = 20): Promise<ApiResponse<TestRun[]>> {
+    return this.request<ApiResponse<TestRun[]>>(`/prompts/${promptId}/runs?page=${page}&page_size=${pageSize}`);
+  }
+
+  async getRun(id: string): Promise<TestRun> {
+    return this.request<TestRun>(`/runs/${id}`);
+  }
+
+  async rerun(id: string): Promise<TestRun> {
+    return this.request<TestRun>(`/runs/${id}/rerun`, {
+      method: 'POST',
+    });
+  }
+
+  async deleteRun(id: string): Promise<void> {
+    return this.request<void>(`/runs/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
+  // 评测数据集
+  async getDatasets(projectId: string): Promise<ApiResponse<Dataset[]>> {
+    return this.request<ApiResponse<Dataset[]>>(`/projects/${projectId}/datasets`);
+  }
+
+  async createDataset(projectId: string, data: { name: string; description?: string; rows?: DatasetRowInput[] }): Promise<Dataset> {
+    return this.request<Dataset>(`/projects/${projectId}/datasets`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async getDataset(id: string): Promise<Dataset> {
+    return this.request<Dataset>(`/datasets/${id}`);
+  }
+
+  async updateDataset(id: string, data: { name?: string; description?: string }): Promise<Dataset> {
+    return this.request<Dataset>(`/datasets/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async deleteDataset(id: string): Promise<void> {
+    return this.request<void>(`/datasets/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
+  async addDatasetRows(datasetId: string, rows: DatasetRowInput[]): Promise<ApiResponse<DatasetRow[]>> {
+    return this.request<ApiResponse<DatasetRow[]>>(`/datasets/${datasetId}/rows`, {
+      method: 'POST',
+      body: JSON.stringify({ rows }),
+    });
+  }
+
+  async updateDatasetRow(datasetId: string, rowId: string, data: DatasetRowInput): Promise<DatasetRow> {
+    return this.request<DatasetRow>(`/datasets/${datasetId}/rows/${rowId}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async deleteDatasetRow(datasetId: string, rowId: string): Promise<void> {
+    return this.request<void>(`/datasets/${datasetId}/rows/${rowId}`, {
+      method: 'DELETE',
+    });
+  }
+
+  // 批量评测
+  async getEvaluations(datasetId: string, params?: { prompt_id?: string; status?: string; page?: number; page_size?: number }): Promise<ApiResponse<Evaluation[]>> {
+    const queryParams = new URLSearchParams();
+    Object.entries(params || {}).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') queryParams.append(key, String(value));
+    });
+
+    return this.request<ApiResponse<Evaluation[]>>(`/datasets/${datasetId}/evaluations?${queryParams}`);
+  }
+
+  async createEvaluation(datasetId: string, data: { prompt_id: string; provider?: string; model?: string; temperature?: number; top_p?: number; max_tokens?: number; concurrency?: number }): Promise<Evaluation> {
+    return this.request<Evaluation>(`/datasets/${datasetId}/evaluations`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async getEvaluation(id: string): Promise<Evaluation> {
+    return this.request<Evaluation>(`/evaluations/${id}`);
+  }
+
+  async getEvaluationResults(id: string, page = 1, pageSize = 20, status?: string): Promise<ApiResponse<EvaluationResult[]>> {
+    const queryParams = new URLSearchParams({ page: String(page), page_size: String(pageSize) });
+    if (status) queryParams.append('status', status);
+
+    return this.request<ApiResponse<EvaluationResult[]>>(`/evaluations/${id}/results?${queryParams}`);
+  }
+
+  async cancelEvaluation(id: string): Promise<void> {
+    return this.request<void>(`/evaluations/${id}/cancel`, {
+      method: 'POST',
+    });
+  }
+
+  async deleteEvaluation(id: string): Promise<void> {
+    return this.request<void>(`/evaluations/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
+  async compareEvaluations(datasetId: string, evaluationIds: string[]): Promise<EvaluationComparison> {
+    return this.request<EvaluationComparison>(`/datasets/${datasetId}/compare?evaluations=${evaluationIds.join(',')}`);
+  }
+
+  // 回归测试用例与版本检查
+  async getTestCases(entityId: string): Promise<PromptTestCase[]> {
+    return this.request<PromptTestCase[]>(`/prompt-entities/${entityId}/test-cases`);
+  }
+
+  async createTestCase(entityId: string, data: PromptTestCaseInput): Promise<PromptTestCase> {
+    return this.request<PromptTestCase>(`/prompt-entities/${entityId}/test-cases`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async updateTestCase(id: string, data: PromptTestCaseInput): Promise<PromptTestCase> {
+    return this.request<PromptTestCase>(`/test-cases/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async deleteTestCase(id: string): Promise<void> {
+    return this.request<void>(`/test-cases/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
+  async getPromptCheck(promptId: string): Promise<PromptCheck> {
+    return this.request<PromptCheck>(`/prompts/${promptId}/check`);
+  }
+
+  async runPromptCheck(promptId: string): Promise<Pick<PromptCheck, 'prompt_id' | 'version' | 'check_status'>> {
+    return this.request<Pick<PromptCheck, 'prompt_id' | 'version' | 'check_status'>>(`/prompts/${promptId}/check`, {
+      method: 'POST',
+    });
+  }
+
+  async acceptPromptCheck(promptId: string): Promise<Prompt> {
+    return this.request<Prompt>(`/prompts/${promptId}/check/accept`, {
+      method: 'POST',
+    });
+  }
+
+  async updatePromptStatus(id: string, status: PromptStatus): Promise<Prompt> {
+    return this.request<Prompt>(`/prompts/${id}/status`, {
+      method: 'PUT',
+      body: JSON.stringify({ status }),
+    });
+  }
+
+  // 变更审批
+  async getProjectReviews(projectId: string, params?: {
+    status?: ReviewStatus;
+    name?: string;
+    page?: number;
+    page_size?: number;
+  }): Promise<ApiResponse<Prompt[]>> {
+    const queryParams = new URLSearchParams();
+    if (params?.status) queryParams.append('status', params.status);
+    if (params?.name) queryParams.append('name', params.name);
+    if (params?.page) queryParams.append('page', params.page.toString());
+    if (params?.page_size) queryParams.append('page_size', params.page_size.toString());
+    return this.request<ApiResponse<Prompt[]>>(`/projects/${projectId}/reviews?${queryParams}`);
+  }
+
+  async updateReviewSettings(projectId: string, requiredApprovals: number): Promise<{ project_id: string; required_approvals: number }> {
+    return this.request<{ project_id: string; required_approvals: number }>(`/projects/${projectId}/review-settings`, {
+      method: 'PUT',
+      body: JSON.stringify({ required_approvals: requiredApprovals }),
+    });
+  }
+
+  async getPromptReview(promptId: string): Promise<PromptReviewSummary> {
+    return this.request<PromptReviewSummary>(`/prompts/${promptId}/review`);
+  }
+
+  async submitPromptReview(promptId: string, comment?: string): Promise<PromptReview & { review_status: ReviewStatus; approvals: number }> {
+    return this.request<PromptReview & { review_status: ReviewStatus; approvals: number }>(`/prompts/${promptId}/submit`, {
+      method: 'POST',
+      body: JSON.stringify({ comment }),
+    });
+  }
+
+  async createPromptReview(promptId: string, data: {
+    action: 'comment' | 'approve' | 'reject';
+    comment?: string;
+  }): Promise<PromptReview & { review_status: ReviewStatus; approvals: number }> {
+    return this.request<PromptReview & { review_status: ReviewStatus; approvals: number }>(`/prompts/${promptId}/reviews`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
   // 标签管理
-  async getTags(): Promise<ApiResponse<Tag[]>> {
-    return this.request<ApiResponse<Tag[]>>('/tags');
+  async getTags(list?: ListParams): Promise<ApiResponse<Tag[]>> {
+    return this.request<ApiResponse<Tag[]>>(`/tags?${this.listParams(list)}`);
   }
 
   async createTag(data: { name: string; color?: string }): Promise<Tag> {
@@ -392,8 +836,8 @@ class ApiService {
   }
 
   // 分类管理
-  async getCategories(): Promise<ApiResponse<{ id: string; name: string; color: string; created_at: string }[]>> {
-    return this.request<ApiResponse<{ id: string; name: string; color: string; created_at: string }[]>>('/categories');
+  async getCategories(list?: ListParams): Promise<ApiResponse<{ id: string; name: string; color: string; created_at: string }[]>> {
+    return this.request<ApiResponse<{ id: string; name: string; color: string; created_at: string }[]>>(`/categories?${this.listParams(list)}`);
   }
   async createCategory(data: { name: string; color?: string }): Promise<{ id: string; name: string; color: string; created_at: string }> {
     return this.request<{ id: string; name: string; color: string; created_at: string }>(`/categories`, {
@@ -413,12 +857,54 @@ class ApiService {
     });
   }
 
+  // 回收站
+  async search(q: string, params?: {
+    project_id?: string;
+    status?: PromptStatus[];
+    page?: number;
+    page_size?: number;
+  }): Promise<ApiResponse<SearchHit[]>> {
+    const queryParams = new URLSearchParams({ q });
+    if (params?.project_id) queryParams.append('project_id', params.project_id);
+    if (params?.status?.length) queryParams.append('status', params.status.join(','));
+    if (params?.page) queryParams.append('page', params.page.toString());
+    if (params?.page_size) queryParams.append('page_size', params.page_size.toString());
+    return this.request<ApiResponse<SearchHit[]>>(`/search?${queryParams}`);
+  }
+
+  async getTrash(params?: {
+    type?: TrashType;
+    project_id?: string;
+    page?: number;
+    page_size?: number;
+  }): Promise<ApiResponse<TrashItem[]>> {
+    const queryParams = new URLSearchParams();
+    if (params?.type) queryParams.append('type', params.type);
+    if (params?.project_id) queryParams.append('project_id', params.project_id);
+    if (params?.page) queryParams.append('page', params.page.toString());
+    if (params?.page_size) queryParams.append('page_size', params.page_size.toString());
+    return this.request<ApiResponse<TrashItem[]>>(`/trash?${queryParams}`);
+  }
+
+  async restoreTrashItem<T = unknown>(type: TrashType, id: string): Promise<T> {
+    return this.request<T>(`/trash/${type}/${id}/restore`, {
+      method: 'POST',
+    });
+  }
+
+  async purgeTrashItem(type: TrashType, id: string): Promise<void> {
+    return this.request<void>(`/trash/${type}/${id}`, {
+      method: 'DELETE',
+    });
+  }
+
   // 导入导出
   async exportData(projectIds: string[], format: 'json' | 'csv'): Promise<Blob> {
     const response = await fetch(`${API_BASE_URL}/export`, {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
       body: JSON.stringify({ project_ids: projectIds, format }),
     });
@@ -435,6 +921,7 @@ class ApiService {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
       body: JSON.stringify({ project_ids: [projectId], format }),
     });
@@ -453,6 +940,7 @@ class ApiService {
 
     const response = await fetch(`${API_BASE_URL}/import`, {
       method: 'POST',
+      headers: authHeaders(),
       body: formData,
     });
 
@@ -5,28 +5,106 @@ export interface Project {
   created_at: string;
   updated_at: string;
   prompts?: Prompt[];
+  prompt_entities?: PromptEntity[];
   tags?: Tag[];
+  required_approvals?: number;
+  deleted_at?: string | null;
+}
+
+// 提示词实体，同一提示词的所有版本归属于同一个实体
+export interface PromptEntity {
+  id: string;
+  project_id: string;
+  name: string;
+  description?: string;
+  category?: string;
+  created_at?: string;
+  updated_at?: string;
+  tags?: Tag[];
+}
+
+export interface PromptVersionSummary {
+  id: string;
+  version: string;
+  created_at: string;
+}
+
+export interface PromptEntityListItem extends PromptEntity {
+  version_count: number;
+  latest_version: PromptVersionSummary | null;
+  labels: { label: string; prompt_id: string; version: string }[];
+  labeled_version?: PromptVersionSummary;
 }
 
 export interface Prompt {
   id: string;
   project_id: string;
+  entity_id?: string;
   name: string;
   version: string;
   content: string;
   description: string;
   category?: string;
+  variables?: PromptVariable[];
+  assertions?: Assertion[];
+  check_status?: CheckStatus;
+  check_accepted?: boolean;
+  review_status?: ReviewStatus;
+  base_id?: string;
+  status?: PromptStatus;
+  deleted_at?: string | null;
   created_at: string;
   project?: Project;
   tags?: Tag[];
   history?: PromptHistory[];
 }
 
+export interface PromptVariable {
+  name: string;
+  type: 'string' | 'number' | 'integer' | 'boolean' | 'array' | 'object';
+  required: boolean;
+  default?: unknown;
+  description?: string;
+}
+
 export interface Tag {
   id: string;
   name: string;
   color: string;
   created_at: string; // Ensure this is always provided
+  deleted_at?: string | null;
+}
+
+// TrashType 回收站中的记录类型
+export type TrashType = 'project' | 'prompt' | 'tag' | 'category';
+
+// TrashItem 回收站中的一条记录，purge_at 为到期后彻底删除的时间，永久保留时为空
+export interface TrashItem {
+  type: TrashType;
+  id: string;
+  name: string;
+  project_id?: string;
+  version?: string;
+  deleted_at: string;
+  purge_at?: string;
+}
+
+// SearchHit 全文搜索命中的提示词版本，highlights 为已转义的 HTML 片段，命中处以 <mark> 标记
+export interface SearchHit {
+  prompt_id: string;
+  entity_id: string;
+  project_id: string;
+  project_name: string;
+  name: string;
+  version: string;
+  status: PromptStatus;
+  created_at: string;
+  score: number;
+  highlights: {
+    name?: string;
+    description?: string;
+    content?: string;
+  };
 }
 
 export interface PromptHistory {
@@ -35,10 +113,332 @@ export interface PromptHistory {
   operation: string;
   old_content: string;
   new_content: string;
+  author?: string;
   created_at: string;
   prompt?: Prompt;
 }
 
+export interface User {
+  id: string;
+  username: string;
+  role: 'admin' | 'editor' | 'viewer';
+  disabled: boolean;
+  last_login_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CustomProvider {
+  id: string;
+  name: string;
+  display_name: string;
+  base_url: string;
+  auth_scheme: 'bearer' | 'header' | 'none';
+  auth_header: string;
+  default_model: string;
+  models: string[];
+  extra_headers: Record<string, string>;
+  stream_usage: boolean;
+  has_api_key: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CustomProviderInput {
+  name?: string;
+  display_name?: string;
+  base_url?: string;
+  auth_scheme?: 'bearer' | 'header' | 'none';
+  auth_header?: string;
+  default_model?: string;
+  models?: string[];
+  extra_headers?: Record<string, string>;
+  stream_usage?: boolean;
+  api_key?: string;
+}
+
+// ChatMetrics 一次模型调用的用量、耗时和费用，cost 仅在价格表中有该模型时返回
+export interface ChatMetrics {
+  provider: string;
+  model: string;
+  finish_reason: string;
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
+  time_to_first_token_ms?: number;
+  latency_ms: number;
+  cost?: number;
+  currency?: string;
+}
+
+// Assertion 对模型输出的断言，llm_judge 通过评审模型按 rubric 打分
+export interface Assertion {
+  name?: string;
+  type: 'equals' | 'contains' | 'not_contains' | 'regex' | 'json_schema' | 'length' | 'llm_judge';
+  value?: string;
+  ignore_case?: boolean;
+  schema?: Record<string, unknown>;
+  min?: number;
+  max?: number;
+  rubric?: string;
+  provider?: string;
+  model?: string;
+  threshold?: number;
+  weight?: number;
+}
+
+// AssertionResult 单条断言的结果，score 在 0 到 1 之间
+export interface AssertionResult {
+  name?: string;
+  type: Assertion['type'];
+  passed: boolean;
+  score: number;
+  reason: string;
+  metrics?: ChatMetrics;
+}
+
+// AssertionReport 全部断言的结果，score 为按权重的平均分
+export interface AssertionReport {
+  passed: boolean;
+  score: number;
+  results: AssertionResult[];
+}
+
+// TestTarget 对比测试中的一个模型及其参数，未指定的参数沿用请求中的值
+export interface TestTarget {
+  label?: string;
+  provider?: string;
+  model?: string;
+  temperature?: number;
+  top_p?: number;
+  max_tokens?: number;
+}
+
+// TestTargetResult 对比测试中单个模型的结果，target 为其在 targets 中的下标
+export interface TestTargetResult {
+  target: number;
+  label: string;
+  provider: string;
+  model: string;
+  response: string;
+  metrics?: ChatMetrics;
+  run_id: string;
+  error?: string;
+  error_type?: string;
+  assertions?: AssertionReport;
+}
+
+// TestRun 测试台的一次调用记录，kind 为 test|optimize
+export interface TestRun {
+  id: string;
+  kind: 'test' | 'optimize';
+  project_id: string;
+  prompt_id: string;
+  rerun_of: string;
+  provider: string;
+  model: string;
+  temperature: number | null;
+  top_p: number | null;
+  max_tokens: number;
+  stream: boolean;
+  messages: { role: string; content: string }[];
+  response: string;
+  status: 'success' | 'error' | 'canceled';
+  error: string;
+  error_type: string;
+  response_model: string;
+  finish_reason: string;
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
+  time_to_first_token_ms: number;
+  latency_ms: number;
+  cost: number | null;
+  currency: string;
+  actor: string;
+  created_at: string;
+}
+
+// Dataset 评测数据集，每行包含模板变量、可选的用户输入和期望输出
+export interface Dataset {
+  id: string;
+  project_id: string;
+  name: string;
+  description: string;
+  created_at: string;
+  updated_at: string;
+  rows?: DatasetRow[];
+  row_count?: number;
+}
+
+export interface DatasetRow {
+  id: string;
+  dataset_id: string;
+  position: number;
+  variables: Record<string, unknown>;
+  input: string;
+  expected_output: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface DatasetRowInput {
+  variables?: Record<string, unknown>;
+  input?: string;
+  expected_output?: string;
+}
+
+// CheckStatus 提示词版本的回归检查状态，空字符串表示提示词没有测试用例
+export type CheckStatus = '' | 'pending' | 'passed' | 'failed';
+
+// PromptTestCase 提示词的回归测试用例，每个新版本创建后都会执行
+export interface PromptTestCase {
+  id: string;
+  entity_id: string;
+  name: string;
+  variables: Record<string, unknown>;
+  messages: { role: string; content: string }[];
+  assertions: Assertion[];
+  provider: string;
+  model: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface PromptTestCaseInput {
+  name?: string;
+  variables?: Record<string, unknown>;
+  messages?: { role: string; content: string }[];
+  assertions?: Assertion[];
+  provider?: string;
+  model?: string;
+}
+
+// PromptCheckResult 回归检查中单个测试用例的结果
+export interface PromptCheckResult {
+  id: string;
+  prompt_id: string;
+  test_case_id: string;
+  position: number;
+  name: string;
+  status: 'passed' | 'failed' | 'error';
+  output: string;
+  score: number;
+  assertions: Omit<AssertionResult, 'metrics'>[];
+  error: string;
+  error_type: string;
+  provider: string;
+  model: string;
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
+  latency_ms: number;
+  cost: number | null;
+  currency: string;
+  created_at: string;
+}
+
+// PromptCheck 提示词版本的回归检查状态及结果
+export interface PromptCheck {
+  prompt_id: string;
+  version: string;
+  check_status: CheckStatus;
+  check_accepted: boolean;
+  results: PromptCheckResult[];
+}
+
+// PromptStatus 提示词版本的生命周期状态，SDK 默认跳过草稿和已归档的版本
+export type PromptStatus = 'draft' | 'published' | 'deprecated' | 'archived';
+
+// ReviewStatus 提示词版本的审批状态，空字符串表示项目不需要审批
+export type ReviewStatus = '' | 'draft' | 'in_review' | 'approved' | 'rejected';
+
+export type ReviewAction = 'submit' | 'comment' | 'approve' | 'reject';
+
+// PromptReview 提示词版本的一条审批记录
+export interface PromptReview {
+  id: string;
+  prompt_id: string;
+  reviewer: string;
+  action: ReviewAction;
+  comment: string;
+  created_at: string;
+}
+
+// PromptReviewSummary 提议版本相对基础版本的差异及审批情况
+export interface PromptReviewSummary {
+  prompt: Prompt;
+  base: PromptVersionSummary | null;
+  diff: DiffResult;
+  required_approvals: number;
+  approvals: number;
+  approvers: string[];
+  reviews: PromptReview[];
+}
+
+export type EvaluationStatus = 'pending' | 'running' | 'completed' | 'failed' | 'canceled';
+
+// Evaluation 使用某个提示词版本对数据集逐行调用模型的批量评测任务
+export interface Evaluation {
+  id: string;
+  project_id: string;
+  dataset_id: string;
+  prompt_id: string;
+  prompt_version: string;
+  provider: string;
+  model: string;
+  temperature: number | null;
+  top_p: number | null;
+  max_tokens: number;
+  concurrency: number;
+  status: EvaluationStatus;
+  total_rows: number;
+  completed_rows: number;
+  failed_rows: number;
+  total_tokens: number;
+  cost: number | null;
+  currency: string;
+  error: string;
+  actor: string;
+  created_at: string;
+  started_at: string | null;
+  finished_at: string | null;
+}
+
+export interface EvaluationResult {
+  id: string;
+  evaluation_id: string;
+  row_id: string;
+  position: number;
+  messages: { role: string; content: string }[];
+  output: string;
+  expected_output: string;
+  status: 'success' | 'error';
+  error: string;
+  error_type: string;
+  finish_reason: string;
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
+  latency_ms: number;
+  cost: number | null;
+  currency: string;
+  created_at: string;
+}
+
+// EvaluationComparison 同一数据集上多个评测任务按行并排的结果，results 以评测任务 ID 为键
+export interface EvaluationComparison {
+  evaluations: Evaluation[];
+  rows: {
+    row_id: string;
+    position: number;
+    variables: Record<string, unknown>;
+    input: string;
+    expected_output: string;
+    results: Record<string, EvaluationResult | null>;
+  }[];
+}
+
 export interface DiffResult {
   additions: number;
   deletions: number;
@@ -49,5 +449,18 @@ export interface DiffResult {
 export interface ApiResponse<T> {
   data: T;
   total?: number;
+  page?: number;
+  page_size?: number;
+  next_cursor?: string;
   error?: string;
 }
+
+// ListParams 列表接口的分页、排序和字段选择参数，不传分页参数时返回全部记录
+// sort 以 - 开头表示倒序；cursor 传空字符串时从第一页开始游标分页
+export interface ListParams {
+  page?: number;
+  page_size?: number;
+  cursor?: string;
+  sort?: string;
+  fields?: string[];
+}